@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// argSpec describes a positional argument of a command
+type argSpec struct {
+	name        string
+	description string
+	optional    bool
+	// choices limits the argument to a fixed set of words
+	choices []string
+}
+
+// flagSpec describes a --flag. A flag without a value placeholder is a boolean switch
+type flagSpec struct {
+	name        string
+	value       string
+	description string
+	// numeric flags are checked to be whole numbers while parsing
+	numeric bool
+	choices []string
+}
+
+// commandArgs are the validated arguments a command is called with
+type commandArgs struct {
+	cmd    command
+	values []string
+	flags  map[string]string
+}
+
+// arg returns the i-th positional argument or "" when an optional one was left out
+func (a commandArgs) arg(i int) string {
+	if i < len(a.values) {
+		return a.values[i]
+	}
+	return ""
+}
+
+func (a commandArgs) has(name string) bool {
+	_, ok := a.flags[name]
+	return ok
+}
+
+func (a commandArgs) flag(name string) string {
+	return a.flags[name]
+}
+
+// intFlag returns the value of a numeric flag, or fallback when it wasn't given
+func (a commandArgs) intFlag(name string, fallback int) int {
+	value, ok := a.flags[name]
+	if !ok {
+		return fallback
+	}
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+// usageError reports arguments that are wrong in a way the specs can't express
+func (a commandArgs) usageError(format string, args ...any) error {
+	return a.cmd.usageError(format, args...)
+}
+
+// usage builds the usage line from the argument and flag specs, e.g. "catch {pokemon_name} [--seed {n}]"
+func (c command) usage() string {
+	parts := []string{c.name}
+	for _, arg := range c.args {
+		name := arg.name
+		if len(arg.choices) > 0 {
+			name = strings.Join(arg.choices, "|")
+		}
+		if arg.optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "{"+name+"}")
+		}
+	}
+	for _, flag := range c.flags {
+		parts = append(parts, "["+flag.usage()+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f flagSpec) usage() string {
+	switch {
+	case f.value == "":
+		return "--" + f.name
+	case len(f.choices) > 0:
+		return "--" + f.name + " {" + strings.Join(f.choices, "|") + "}"
+	default:
+		return "--" + f.name + " {" + f.value + "}"
+	}
+}
+
+// parseArgs checks the words typed after the command name against its specs.
+// Flags may stand anywhere, "--" ends them
+func (c command) parseArgs(words []string) (commandArgs, error) {
+	args := commandArgs{cmd: c, flags: map[string]string{}}
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if word == "--" {
+			args.values = append(args.values, words[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(word, "--") {
+			args.values = append(args.values, word)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(word, "--"), "=")
+		index := slices.IndexFunc(c.flags, func(flag flagSpec) bool { return flag.name == name })
+		if index < 0 {
+			return args, c.usageError("unknown flag --%s", name)
+		}
+		spec := c.flags[index]
+
+		switch {
+		case spec.value == "" && hasValue:
+			return args, c.usageError("flag --%s takes no value", name)
+		case spec.value == "":
+			value = "true"
+		case !hasValue:
+			if i+1 == len(words) {
+				return args, c.usageError("flag --%s needs a value", name)
+			}
+			i++
+			value = words[i]
+		}
+		if spec.numeric {
+			if _, err := strconv.Atoi(value); err != nil {
+				return args, c.usageError("flag --%s expects a whole number, got '%s'", name, value)
+			}
+		}
+		if len(spec.choices) > 0 && !slices.Contains(spec.choices, value) {
+			return args, c.usageError("flag --%s expects %s, got '%s'", name, strings.Join(spec.choices, " or "), value)
+		}
+		args.flags[name] = value
+	}
+
+	required := 0
+	for _, arg := range c.args {
+		if !arg.optional {
+			required++
+		}
+	}
+	if len(args.values) < required {
+		return args, c.usageError("missing {%s}", c.args[len(args.values)].name)
+	}
+	if len(args.values) > len(c.args) {
+		return args, c.usageError("too many arguments")
+	}
+
+	for i, value := range args.values {
+		if choices := c.args[i].choices; len(choices) > 0 && !slices.Contains(choices, value) {
+			return args, c.usageError("unknown argument '%s', expected %s", value, strings.Join(choices, " or "))
+		}
+	}
+	return args, nil
+}
+
+// usageError reports wrong arguments together with the usage line of the command
+func (c command) usageError(format string, args ...any) error {
+	return usageError("%s command error: %s. Usage: %s", c.name, fmt.Sprintf(format, args...), c.usage())
+}
+
+// splitWords splits a command line on whitespace. Single or double quotes keep spaces
+// inside one word and a backslash escapes the next character
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, char := range line {
+		switch {
+		case escaped:
+			word.WriteRune(char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				word.WriteRune(char)
+			}
+		case char == '"' || char == '\'':
+			quote, inWord = char, true
+		case char == ' ' || char == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(char)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, usageError("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, usageError("nothing to escape at the end of the line")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand/v2"
@@ -10,47 +11,160 @@ import (
 	"github.com/fatih/color"
 )
 
-func startBattle(firstContestant, secondContestant pokeapi.Battler) error {
-	color.Unset()
-	defer color.Unset()
+type battleTurn struct {
+	Attacker       string `json:"attacker"`
+	Defender       string `json:"defender"`
+	Hit            bool   `json:"hit"`
+	Damage         int    `json:"damage"`
+	DefenderHealth int    `json:"defender_health"`
+	// Status is set when the hit left the defender with a status
+	Status string `json:"status,omitempty"`
+}
+
+type battleResult struct {
+	First  string       `json:"first"`
+	Second string       `json:"second"`
+	Turns  []battleTurn `json:"turns"`
+	Winner string       `json:"winner"`
+	// Draw is set when nobody won within maxBattleTurns
+	Draw bool `json:"draw,omitempty"`
+	// WildLevel is set when Second is a wild Pokémon
+	WildLevel int `json:"wild_level,omitempty"`
+
+	// how the second Pokémon is left after the battle
+	secondHealth int
+	secondStatus string
+}
+
+// inflictedStatus is the status the hits of a Pokémon of the type may cause
+var inflictedStatus = map[string]string{
+	"electric": "paralysis",
+	"fire":     "burn",
+	"poison":   "poison",
+	"ice":      "freeze",
+	"grass":    "sleep",
+}
+
+// inflictsStatus is the status the hits of the Pokémon may cause, going by its first type that has one
+func inflictsStatus(pokemon pokeapi.Pokemon) string {
+	for _, value := range pokemon.Types {
+		if status, ok := inflictedStatus[value.Type.Name]; ok {
+			return status
+		}
+	}
+	return ""
+}
+
+// maxBattleTurns ends battles between Pokémon that can't hurt each other
+const maxBattleTurns = 200
 
+// simulateBattle plays the whole battle at once. Text output replays it turn by turn
+func simulateBattle(ctx context.Context, firstContestant, secondContestant pokeapi.Battler, rng *rand.Rand) (battleResult, error) {
 	const treshold = 30
 
-	for firstContestant.Health > 0 && secondContestant.Health > 0 {
-		damageFirst := rand.IntN(int(math.Round(float64(firstContestant.Attack*secondContestant.Defense) / 100)))
-		damageSecond := rand.IntN(int(math.Round(float64(secondContestant.Attack*firstContestant.Defense) / 100)))
-
-		chanceToAttackFirst := rand.IntN(firstContestant.Experience) + treshold
-		chanceToAttackSecond := rand.IntN(secondContestant.Experience) + treshold
-
-		time.Sleep(800 * time.Millisecond)
-		if chanceToAttackFirst > secondContestant.Parry {
-			if secondContestant.Health -= damageFirst; secondContestant.Health <= 0 {
-				fmt.Printf("%s attacked! %s's health is 0\n", firstContestant.Name, secondContestant.Name)
-				color.Set(color.FgGreen)
-				fmt.Printf("%s is the WINNER!\n", firstContestant.Name)
-				return nil
+	res := battleResult{First: firstContestant.Name, Second: secondContestant.Name, Turns: []battleTurn{}}
+
+	attack := func(attacker pokeapi.Battler, defender *pokeapi.Battler, damage, chanceToAttack int) bool {
+		turn := battleTurn{Attacker: attacker.Name, Defender: defender.Name}
+		if chanceToAttack > defender.Parry {
+			defender.Health -= damage
+			turn.Hit, turn.Damage = true, damage
+
+			// like Thunder Shock, one hit in ten leaves a status
+			if attacker.Inflicts != "" && defender.Status == "" && defender.Health > 0 && rng.IntN(10) == 0 {
+				defender.Status, turn.Status = attacker.Inflicts, attacker.Inflicts
 			}
-			fmt.Printf("%s attacked! %s's health is %d\n", firstContestant.Name, secondContestant.Name, secondContestant.Health)
-		} else {
-			fmt.Printf("%s missed\n", firstContestant.Name)
 		}
+		turn.DefenderHealth = max(defender.Health, 0)
+		res.Turns = append(res.Turns, turn)
 
-		time.Sleep(800 * time.Millisecond)
-		if chanceToAttackSecond > firstContestant.Parry {
-			if firstContestant.Health -= damageSecond; firstContestant.Health <= 0 {
-				fmt.Printf("%s attacked! %s's health is 0\n", secondContestant.Name, firstContestant.Name)
-				color.Set(color.FgHiGreen)
-				fmt.Printf("%s is the WINNER!\n", secondContestant.Name)
-				return nil
-			}
-			fmt.Printf("%s attacked! %s's health is %d\n", secondContestant.Name, firstContestant.Name, firstContestant.Health)
-		} else {
-			fmt.Printf("%s missed\n", secondContestant.Name)
+		if defender.Health <= 0 {
+			res.Winner = attacker.Name
+			return true
+		}
+		return false
+	}
 
+	for firstContestant.Health > 0 && secondContestant.Health > 0 {
+		if err := ctx.Err(); err != nil {
+			return battleResult{}, err
 		}
+		if len(res.Turns) >= maxBattleTurns {
+			res.Draw = true
+			break
+		}
+
+		// a hit that lands deals at least 1 damage, even between the weakest Pokémon
+		damageFirst := 1 + rng.IntN(max(int(math.Round(float64(firstContestant.Attack*secondContestant.Defense)/100)), 1))
+		damageSecond := 1 + rng.IntN(max(int(math.Round(float64(secondContestant.Attack*firstContestant.Defense)/100)), 1))
+
+		chanceToAttackFirst := rng.IntN(max(firstContestant.Experience, 1)) + treshold
+		chanceToAttackSecond := rng.IntN(max(secondContestant.Experience, 1)) + treshold
 
+		if attack(firstContestant, &secondContestant, damageFirst, chanceToAttackFirst) {
+			break
+		}
+		if attack(secondContestant, &firstContestant, damageSecond, chanceToAttackSecond) {
+			break
+		}
+	}
+
+	res.secondHealth, res.secondStatus = max(secondContestant.Health, 0), secondContestant.Status
+	return res, nil
+}
+
+func (r battleResult) printText(ctx context.Context) error {
+	color.Set(color.FgBlue)
+	if r.WildLevel > 0 {
+		fmt.Printf("The %s vs wild %s (Lv. %d) battle has begun\n", r.First, r.Second, r.WildLevel)
+	} else {
+		fmt.Printf("The %s vs %s battle has begun\n", r.First, r.Second)
+	}
+	color.Unset()
+	defer color.Unset()
+
+	for _, turn := range r.Turns {
+		if err := waitTurn(ctx); err != nil {
+			return err
+		}
+
+		if turn.Hit {
+			fmt.Printf("%s attacked! %s's health is %d\n", turn.Attacker, turn.Defender, turn.DefenderHealth)
+			if turn.Status != "" {
+				fmt.Printf("%s suffers from %s\n", turn.Defender, turn.Status)
+			}
+		} else {
+			fmt.Printf("%s missed\n", turn.Attacker)
+		}
 	}
 
+	if r.Winner != "" {
+		color.Set(color.FgGreen)
+		fmt.Printf("%s is the WINNER!\n", r.Winner)
+	}
+	if r.Draw {
+		color.Set(color.FgYellow)
+		fmt.Printf("Neither Pokémon could win after %d turns, the battle is a draw\n", len(r.Turns))
+	}
+	if r.WildLevel > 0 {
+		color.Set(color.FgBlue)
+		if r.Winner == r.First {
+			fmt.Printf("The wild %s fainted\n", r.Second)
+		} else {
+			fmt.Printf("The wild %s is still here, catch it, battle it or flee\n", r.Second)
+		}
+	}
 	return nil
 }
+
+func waitTurn(ctx context.Context) error {
+	timer := time.NewTimer(800 * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+var (
+	testPikachu = pokeapi.Battler{Name: "pikachu", Health: 35, Attack: 55, Defense: 40, Parry: 50, Experience: 112}
+	testZubat   = pokeapi.Battler{Name: "zubat", Health: 40, Attack: 45, Defense: 35, Parry: 40, Experience: 49}
+)
+
+func TestScaleToLevel(t *testing.T) {
+	tests := []struct {
+		level                   int
+		health, attack, defense int
+	}{
+		{1, 20, 22, 17},
+		{2, 20, 23, 18},
+		{50, 40, 45, 35},
+		{100, 60, 67, 52},
+	}
+
+	for _, test := range tests {
+		got := scaleToLevel(testZubat, test.level)
+		if got.Health != test.health || got.Attack != test.attack || got.Defense != test.defense {
+			t.Errorf("level %d: health %d, attack %d, defense %d, want %d, %d, %d",
+				test.level, got.Health, got.Attack, got.Defense, test.health, test.attack, test.defense)
+		}
+	}
+}
+
+func TestSimulateBattleLowLevelWild(t *testing.T) {
+	wild := scaleToLevel(testZubat, 2)
+
+	for seed := range uint64(200) {
+		res, err := simulateBattle(context.Background(), testPikachu, wild, rand.New(rand.NewPCG(seed, seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Winner == "" {
+			t.Fatalf("seed %d: the battle ended after %d turns without a winner", seed, len(res.Turns))
+		}
+		for _, turn := range res.Turns {
+			if turn.Hit && turn.Damage < 1 {
+				t.Fatalf("seed %d: %s hit for %d damage", seed, turn.Attacker, turn.Damage)
+			}
+		}
+	}
+}
+
+func TestSimulateBattleDraw(t *testing.T) {
+	// neither can get past the other's special defense
+	shuckle := pokeapi.Battler{Name: "shuckle", Health: 20, Attack: 10, Defense: 230, Parry: 230, Experience: 177}
+
+	res, err := simulateBattle(context.Background(), shuckle, shuckle, rand.New(rand.NewPCG(1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Draw || res.Winner != "" {
+		t.Errorf("draw %v, winner %q, want a draw", res.Draw, res.Winner)
+	}
+	if len(res.Turns) != maxBattleTurns {
+		t.Errorf("%d turns, want %d", len(res.Turns), maxBattleTurns)
+	}
+}
+
+func TestSimulateBattleCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := simulateBattle(ctx, testPikachu, testZubat, rand.New(rand.NewPCG(1, 1)))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("simulateBattle() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+)
+
+var (
+	// errNotCaught is returned along with the result when no Pokémon showed up or it broke free
+	errNotCaught     = errors.New("wasn't caught")
+	errAlreadyCaught = errors.New("is already in your Pokedex")
+)
+
+// pokeballs are the balls that can be thrown and how much they raise the catch rate
+var pokeballs = map[string]float64{
+	"poke":   1,
+	"great":  1.5,
+	"ultra":  2,
+	"master": 255,
+}
+
+var ballNames = map[string]string{
+	"poke":   "Poke Ball",
+	"great":  "Great Ball",
+	"ultra":  "Ultra Ball",
+	"master": "Master Ball",
+}
+
+// statusModifiers make Pokémon that suffer from a status easier to catch
+var statusModifiers = map[string]float64{
+	"sleep":     2,
+	"freeze":    2,
+	"paralysis": 1.5,
+	"burn":      1.5,
+	"poison":    1.5,
+}
+
+// throwBall uses the catch formula of generations III and IV. The ball shakes up to three
+// times, each shake check that passes brings the Pokémon closer to being caught
+func throwBall(rng *rand.Rand, captureRate, health, maxHealth int, ball, status string) (shakes int, caught bool) {
+	rate := catchRate(captureRate, health, maxHealth, ball, status)
+	if rate >= 255 {
+		return 3, true
+	}
+
+	// four checks have to pass, the game only shows the first three as shakes
+	check := shakeCheck(rate)
+	for i := 0; i < 4; i++ {
+		if float64(rng.IntN(65536)) >= check {
+			return i, false
+		}
+	}
+	return 3, true
+}
+
+// catchRate is the modified catch rate, 255 and more is a sure catch. A Pokémon that hasn't
+// been in a battle has no maxHealth and counts as unhurt
+func catchRate(captureRate, health, maxHealth int, ball, status string) float64 {
+	if maxHealth <= 0 {
+		health, maxHealth = 1, 1
+	}
+
+	modifier := statusModifiers[status]
+	if modifier == 0 {
+		modifier = 1
+	}
+	return float64((3*maxHealth-2*health)*captureRate) * pokeballs[ball] / float64(3*maxHealth) * modifier
+}
+
+// shakeCheck is the bound a random number below 65536 has to stay under for one shake
+func shakeCheck(rate float64) float64 {
+	return 1048560 / math.Sqrt(math.Sqrt(16711680/max(rate, 1)))
+}
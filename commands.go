@@ -1,16 +1,24 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"math/rand/v2"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
-	"github.com/englandrecoil/go-pokedex-cli/internal/pokedraw"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokepaths"
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokesave"
 	"github.com/fatih/color"
 )
@@ -18,18 +26,52 @@ import (
 type command struct {
 	name        string
 	description string
-	callback    func(*pokeapi.Config, ...string) error
+	args        []argSpec
+	flags       []flagSpec
+	// subcommands and examples are shown by 'help <command>'
+	subcommands []argumentHelp
+	examples    []string
+	callback    func(context.Context, *pokeapi.Config, commandArgs) (result, error)
+	// keepCase passes arguments as typed instead of lowercased, e.g. for file paths
+	keepCase bool
 }
 
-var commands = map[string]command{
-	"help": {
+type argumentHelp struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// help and run are registered in init because their callbacks read the registry
+func init() {
+	commands["help"] = command{
 		name:        "help",
 		description: "Displays a help message",
-		callback:    commandHelp,
-	},
+		args: []argSpec{
+			{name: "command", description: "show the usage, arguments and examples of a single command", optional: true},
+		},
+		examples: []string{"help", "help catch"},
+		callback: commandHelp,
+	}
+	commands["run"] = command{
+		name:        "run",
+		description: "Run the commands of a script file line by line",
+		args: []argSpec{
+			{name: "file", description: "script with one command per line, lines starting with '#' are comments"},
+		},
+		flags: []flagSpec{
+			{name: "fail-fast", description: "stop at the first failed command instead of running the rest"},
+			{name: "echo", description: "print each command before running it"},
+		},
+		examples: []string{"run session.pokedex", "run session.pokedex --fail-fast --echo", `run "my sessions/first.pokedex"`},
+		callback: commandRun,
+		keepCase: true,
+	}
+}
+
+var commands = map[string]command{
 	"exit": {
 		name:        "exit",
-		description: "Exit the Pokedex",
+		description: "Save progress and exit the Pokedex",
 		callback:    commandExit,
 	},
 	"clear": {
@@ -39,96 +81,335 @@ var commands = map[string]command{
 	},
 	"map": {
 		name:        "map",
-		description: "Displays the names of the next 20 location areas",
-		callback:    commandMap,
+		description: "Displays the names of the next page of location areas (20 by default)",
+		args: []argSpec{
+			{name: "position", description: "jump to the first or the last page", optional: true, choices: []string{"first", "last"}},
+		},
+		flags: []flagSpec{
+			{name: "page", value: "n", description: "jump to page n", numeric: true},
+			{name: "limit", value: "n", description: "show n areas per page, also for the following pages", numeric: true},
+			{name: "region", value: "name", description: "only show the areas of a region, e.g. sinnoh, 'all' shows every area again"},
+		},
+		examples: []string{"map", "map last", "map --page 3", "map --limit 50", "map --region sinnoh"},
+		callback: commandMap,
 	},
 	"mapb": {
 		name:        "mapb",
-		description: "Displays the names of the previous 20 location areas",
-		callback:    commandBackMap,
+		description: "Displays the names of the previous page of location areas",
+		flags: []flagSpec{
+			{name: "limit", value: "n", description: "show n areas per page, also for the following pages", numeric: true},
+		},
+		examples: []string{"mapb", "mapb --limit 50"},
+		callback: commandBackMap,
 	},
 	"explore": {
-		name:        "explore {location}",
-		description: "List all the Pokémon in a given area",
-		callback:    commandExplore,
+		name:        "explore",
+		description: "Displays all the Pokémon in a given area",
+		args: []argSpec{
+			{name: "location_area", description: "name of a location area, as shown by 'map'"},
+		},
+		flags: []flagSpec{
+			{name: "version", value: "game", description: "only show encounters in one game version, e.g. red or diamond"},
+			{name: "sort", value: "order", description: "sort by rarity, the rarest first, or by name", choices: []string{"rarity", "name"}},
+			{name: "limit", value: "n", description: "show at most n encounters", numeric: true},
+		},
+		examples: []string{"explore canalave-city-area", "explore canalave-city-area --version diamond --sort rarity", "explore canalave-city-area --limit 5"},
+		callback: commandExplore,
+	},
+	"where": {
+		name:        "where",
+		description: "Displays the areas where a Pokémon can be found",
+		args: []argSpec{
+			{name: "pokemon_name", description: "Pokémon to look for"},
+		},
+		flags: []flagSpec{
+			{name: "version", value: "game", description: "only show areas in one game version, e.g. red or diamond"},
+		},
+		examples: []string{"where pikachu", "where magikarp --version red"},
+		callback: commandWhere,
+	},
+	"travel": {
+		name:        "travel",
+		description: "Travels to a location area, Pokémon can only be caught where you are",
+		args: []argSpec{
+			{name: "location_area", description: "area to travel to, as shown by 'map'; without it shows where you are", optional: true},
+		},
+		examples: []string{"travel", "travel canalave-city-area"},
+		callback: commandTravel,
+	},
+	"encounter": {
+		name:        "encounter",
+		description: "Walks around the current area until a wild Pokémon appears",
+		flags: []flagSpec{
+			{name: "method", value: "method", description: "how to look for Pokémon, e.g. walk, surf, old-rod, good-rod or super-rod (default walk)"},
+			{name: "version", value: "game", description: "only meet Pokémon of one game version, e.g. red or diamond"},
+			{name: "seed", value: "n", description: "make the encounter repeatable, the same seed meets the same Pokémon", numeric: true},
+		},
+		examples: []string{"encounter", "encounter --method surf", "encounter --method old-rod --version red"},
+		callback: commandEncounter,
+	},
+	"flee": {
+		name:        "flee",
+		description: "Runs away from the wild Pokémon you met",
+		callback:    commandFlee,
 	},
 	"cache": {
 		name:        "cache",
-		description: "Set the caching interval after which cleaning will occur",
-		callback:    commandCache,
+		description: "Configure, clear or inspect the cache",
+		args: []argSpec{
+			{name: "stats|limit|clear|ttl|reap|duration", description: "what to do with the cache, see below"},
+			{name: "value", description: "size for 'limit', duration for 'ttl' and 'reap'", optional: true},
+		},
+		subcommands: []argumentHelp{
+			{"stats", "show cache settings, usage and hit/miss counters"},
+			{"limit {size}", "limit cache memory, e.g. 64mb (0 means no limit)"},
+			{"clear", "remove everything from the cache"},
+			{"ttl {duration}", "set how long cached entries stay valid"},
+			{"reap {duration}", "set how often expired entries are cleaned"},
+			{"{duration}", "set both the lifetime and the cleaning interval (default 1h)"},
+		},
+		examples: []string{"cache stats", "cache limit 32mb", "cache ttl 2h", "cache 30m"},
+		callback: commandCache,
 	},
 	"catch": {
 		name:        "catch",
-		description: "Catch Pokemon with a certain chance",
-		callback:    commandCatch,
+		description: "Catch Pokémon with a certain chance",
+		args: []argSpec{
+			{name: "pokemon_name", description: "Pokémon living in the area you travelled to, see 'explore'; without it the wild Pokémon you met", optional: true},
+		},
+		flags: []flagSpec{
+			{name: "ball", value: "ball", description: "Pokeball to throw, better ones catch more easily (default poke)", choices: []string{"poke", "great", "ultra", "master"}},
+			{name: "seed", value: "n", description: "make the throw repeatable, the same seed gives the same outcome", numeric: true},
+		},
+		examples: []string{"catch pikachu", "catch pikachu --ball ultra", "catch pikachu --seed 42", "catch"},
+		callback: commandCatch,
 	},
 	"inspect": {
-		name:        "inspect {pokemon_name}",
-		description: "Inspect the caught pokemon",
-		callback:    commandInspect,
+		name:        "inspect",
+		description: "Inspect the caught Pokémon",
+		args: []argSpec{
+			{name: "pokemon_name", description: "Pokémon from your Pokedex"},
+		},
+		flags: []flagSpec{
+			{name: "shiny", description: "draw the shiny variant of the Pokémon"},
+		},
+		examples: []string{"inspect pikachu", "inspect pikachu --shiny"},
+		callback: commandInspect,
 	},
 	"pokedex": {
 		name:        "pokedex",
 		description: "Displays all caught Pokémon",
-		callback:    commandPokedex,
+		flags: []flagSpec{
+			{name: "limit", value: "n", description: "show at most n Pokémon", numeric: true},
+		},
+		callback: commandPokedex,
 	},
 	"color": {
 		name:        "color",
 		description: "Configures the display of color output",
-		callback:    commandColor,
+		args: []argSpec{
+			{name: "mode", description: "only works if the environment variable 'NO_COLOR' is empty", choices: []string{"on", "off"}},
+		},
+		examples: []string{"color off"},
+		callback: commandColor,
+	},
+	"profile": {
+		name:        "profile",
+		description: "Manage trainer profiles",
+		args: []argSpec{
+			{name: "action", description: "what to do, see below", choices: []string{"list", "new", "switch", "delete"}},
+			{name: "name", description: "profile name, letters, digits, '-' and '_'", optional: true},
+		},
+		subcommands: []argumentHelp{
+			{"list", "list trainer profiles, the active one is marked"},
+			{"new {name}", "create a new trainer profile"},
+			{"switch {name}", "save progress and switch to another profile"},
+			{"delete {name}", "delete a profile with all its saves"},
+		},
+		examples: []string{"profile new misty", "profile switch misty"},
+		callback: commandProfile,
+	},
+	"paths": {
+		name:        "paths",
+		description: "Show where saves, cache and settings are stored",
+		callback:    commandPaths,
 	},
 	"battle": {
-		name:        "battle {pokemon_name} {pokemon_name}",
-		description: "Simulate battles between captured Pokémon",
-		callback:    commandBattle,
+		name:        "battle",
+		description: "Simulate battles between two captured Pokémon",
+		args: []argSpec{
+			{name: "pokemon_name1", description: "Pokémon from your Pokedex that attacks first"},
+			{name: "pokemon_name2", description: "Pokémon from your Pokedex it fights against; without it the wild Pokémon you met", optional: true},
+		},
+		flags: []flagSpec{
+			{name: "seed", value: "n", description: "make the battle repeatable, the same seed gives the same battle", numeric: true},
+		},
+		examples: []string{"battle pikachu bulbasaur", "battle pikachu bulbasaur --seed 7", "battle pikachu"},
+		callback: commandBattle,
 	},
 }
 
-func commandHelp(cfg *pokeapi.Config, param ...string) error {
+// aliases are shortcuts for existing commands
+var aliases = map[string]string{
+	"?":  "help",
+	"q":  "exit",
+	"ls": "pokedex",
+	// "run" is taken by scripts, so running away from a wild Pokémon is "flee"
+	"walk": "encounter",
+}
+
+// lookupCommand finds a command by its name or alias
+func lookupCommand(name string) (command, bool) {
+	if target, ok := aliases[name]; ok {
+		name = target
+	}
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+func aliasesOf(name string) []string {
+	var names []string
+	for alias, target := range aliases {
+		if target == name {
+			names = append(names, alias)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+type helpEntry struct {
+	Name        string   `json:"name"`
+	Usage       string   `json:"usage"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+type helpResult struct {
+	Commands []helpEntry `json:"commands"`
+}
+
+type commandHelpResult struct {
+	helpEntry
+	Arguments   []argumentHelp `json:"arguments,omitempty"`
+	Flags       []argumentHelp `json:"flags,omitempty"`
+	Subcommands []argumentHelp `json:"subcommands,omitempty"`
+	Examples    []string       `json:"examples,omitempty"`
+}
+
+func commandHelp(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	if name := args.arg(0); name != "" {
+		cmd, ok := lookupCommand(name)
+		if !ok {
+			err := fmt.Errorf("help command error: %s: %w", name, errUndefinedCommand)
+			if suggestion := closestName(name, slices.Collect(maps.Keys(commands))); suggestion != "" {
+				err = fmt.Errorf("%w. Did you mean %s?", err, suggestion)
+			}
+			return nil, err
+		}
+
+		res := commandHelpResult{
+			helpEntry: helpEntry{
+				Name:        cmd.name,
+				Usage:       cmd.usage(),
+				Description: cmd.description,
+				Aliases:     aliasesOf(cmd.name),
+			},
+			Subcommands: cmd.subcommands,
+			Examples:    cmd.examples,
+		}
+		for _, arg := range cmd.args {
+			res.Arguments = append(res.Arguments, argumentHelp{Name: arg.name, Description: arg.description})
+		}
+		for _, flag := range cmd.flags {
+			res.Flags = append(res.Flags, argumentHelp{Name: flag.usage(), Description: flag.description})
+		}
+		return res, nil
+	}
+
+	res := helpResult{}
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		res.Commands = append(res.Commands, helpEntry{
+			Name:        name,
+			Usage:       commands[name].usage(),
+			Description: commands[name].description,
+			Aliases:     aliasesOf(name),
+		})
+	}
+	return res, nil
+}
+
+func (r helpResult) printText(ctx context.Context) error {
 	color.Set(color.FgYellow)
 	defer color.Unset()
 
 	fmt.Println("Usage:")
-	fmt.Println("  pokedex\t\t\tDisplays all caught Pokémon")
-	fmt.Println()
-	fmt.Println("  map\t\t\t\tDisplays the names of the next 20 location areas")
-	fmt.Println()
-	fmt.Println("  mapb\t\t\t\tDisplays the names of the previous 20 location areas")
-	fmt.Println()
-	fmt.Println("  explore {location_area}\tDisplays all the Pokémon in a given area")
-	fmt.Println()
-	fmt.Println("  inspect {pokemon_name}\tInspect the caught Pokémon\t")
-	fmt.Println()
-	fmt.Println("  catch {pokemon_name}\t\tCatch Pokémon with a certain chance")
-	fmt.Println()
-	fmt.Println("  battle {pokemon_name1}\t\tSimulate battles between two captured Pokémon")
-	fmt.Println("  {pokemon_name2}")
-	fmt.Println()
-	fmt.Println("  help\t\t\t\tDisplays a help message")
-	fmt.Println()
-	fmt.Println("  exit\t\t\t\tExit the Pokedex")
-	fmt.Println()
-	fmt.Println("  clear\t\t\t\tClear the terminal screen")
-	fmt.Println()
-	fmt.Println("  cache {integer_number}\tSet the caching interval(in hours) after which")
-	fmt.Println("  \t\t\t\tcleaning will occur (default value is 1 hour)")
-	fmt.Println()
-	fmt.Println("  color {on/off}\t\tConfigures the display of color output. Only works")
-	fmt.Println("  \t\t\t\tif the environment variable 'NO_COLOR' is empty")
-	fmt.Println("  \t\t\t\t(default option is set to the NO_COLORS value)")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	for _, entry := range r.Commands {
+		description := entry.Description
+		if len(entry.Aliases) > 0 {
+			description += fmt.Sprintf(" (alias: %s)", strings.Join(entry.Aliases, ", "))
+		}
+		fmt.Fprintf(writer, "  %s\t%s\n", entry.Usage, description)
+	}
+	writer.Flush()
+
 	fmt.Println()
+	fmt.Println("Type 'help {command}' to see the arguments and examples of a command.")
+	fmt.Println("Arguments with spaces can be quoted, e.g. run \"my script.pokedex\".")
 	return nil
 }
 
-func commandExit(cfg *pokeapi.Config, params ...string) error {
+func (r commandHelpResult) printText(ctx context.Context) error {
+	fmt.Println(color.YellowString("Usage: ") + r.Usage)
+	fmt.Println(r.Description)
+
+	if len(r.Aliases) > 0 {
+		fmt.Println(color.YellowString("Aliases: ") + strings.Join(r.Aliases, ", "))
+	}
+
+	for _, section := range []struct {
+		title   string
+		entries []argumentHelp
+	}{
+		{"Arguments:", r.Arguments},
+		{"Subcommands:", r.Subcommands},
+		{"Flags:", r.Flags},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Println(color.YellowString(section.title))
+		writer := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+		for _, entry := range section.entries {
+			fmt.Fprintf(writer, "  %s\t%s\n", entry.Name, entry.Description)
+		}
+		writer.Flush()
+	}
+
+	if len(r.Examples) > 0 {
+		fmt.Println(color.YellowString("Examples:"))
+		for _, example := range r.Examples {
+			fmt.Println("  " + example)
+		}
+	}
+	return nil
+}
+
+func commandExit(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
 	if err := pokesave.SaveProgress(cfg); err != nil {
-		return fmt.Errorf("can't save progress before exiting: %w", err)
+		return nil, fmt.Errorf("can't save progress before exiting: %w", err)
 	}
 	defer os.Exit(0)
-	return nil
+	return nil, nil
 }
 
-func commandClear(cfg *pokeapi.Config, params ...string) error {
+func commandClear(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	// escape codes would end up in the JSON or YAML a script reads
+	if outputFormat != outputText {
+		return nil, nil
+	}
+
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
@@ -140,270 +421,757 @@ func commandClear(cfg *pokeapi.Config, params ...string) error {
 
 	cmd.Stdout = os.Stdout
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("clear command error: %w", err)
+		return nil, fmt.Errorf("clear command error: %w", err)
 	}
 
-	return nil
+	return nil, nil
 }
 
-func commandColor(cfg *pokeapi.Config, params ...string) error {
-	if len(params) == 1 {
-		return fmt.Errorf("color command error: no argument provided")
+func commandColor(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	if args.arg(0) == "off" {
+		color.NoColor = true
+		return message("Color output is off"), nil
 	}
+	color.NoColor = false
+	return message("Color output is on"), nil
+}
 
-	switch params[1] {
-	case "off":
-		color.NoColor = true
-		return nil
-	case "on":
-		color.NoColor = false
-		return nil
+func commandMap(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	return showLocationAreas(ctx, cfg, args, pokeapi.Next)
+}
+
+func commandBackMap(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	return showLocationAreas(ctx, cfg, args, pokeapi.Previous)
+}
+
+// showLocationAreas picks the page to show from the arguments and the page shown before.
+// Without arguments it moves one page in the given direction
+func showLocationAreas(ctx context.Context, cfg *pokeapi.Config, args commandArgs, direction pokeapi.Direction) (result, error) {
+	commandName := args.cmd.name
+	// the page is only remembered once it was loaded
+	page := cfg.Locations
+
+	if args.has("region") {
+		region := args.flag("region")
+		if region == "all" {
+			region = ""
+		}
+		if region != page.Region {
+			page = pokeapi.LocationPage{Region: region, Limit: page.Limit}
+		}
+	}
+
+	previousLimit := cmp.Or(page.Limit, pokeapi.DefaultPageSize)
+	limit := previousLimit
+	if args.has("limit") {
+		if limit = args.intFlag("limit", 0); limit < 1 {
+			return nil, args.usageError("--limit must be at least 1")
+		}
+	}
+
+	offset := 0
+	switch {
+	case args.arg(0) == "first":
+	case args.arg(0) == "last":
+		count, err := locationAreaCount(ctx, cfg, page)
+		if err != nil {
+			return nil, wrapLocationsError(ctx, cfg, commandName, page.Region, err)
+		}
+		offset = max(count-1, 0) / limit * limit
+	case args.has("page"):
+		number := args.intFlag("page", 0)
+		if number < 1 {
+			return nil, args.usageError("--page must be at least 1")
+		}
+		offset = (number - 1) * limit
+	case !page.Shown:
+		if direction == pokeapi.Previous {
+			return nil, fmt.Errorf("%s command error: %w", commandName, pokeapi.ErrNoMoreLocations)
+		}
+	case direction == pokeapi.Next:
+		offset = page.Offset + previousLimit
+		if offset >= page.Count {
+			return nil, fmt.Errorf("%s command error: %w", commandName, pokeapi.ErrNoMoreLocations)
+		}
 	default:
-		return fmt.Errorf("color command error: unknown argument")
+		if page.Offset == 0 {
+			return nil, fmt.Errorf("%s command error: %w", commandName, pokeapi.ErrNoMoreLocations)
+		}
+		offset = max(page.Offset-limit, 0)
 	}
-}
+	// after --limit changed, the page that holds the next area is shown
+	offset = offset / limit * limit
 
-func commandMap(cfg *pokeapi.Config, params ...string) error {
-	locations, err := pokeapi.GetLocationAreas(cfg, pokeapi.Next)
+	names, count, err := getLocationAreas(ctx, cfg, page.Region, offset, limit)
 	if err != nil {
-		return fmt.Errorf("map command error: %s", err)
+		return nil, wrapLocationsError(ctx, cfg, commandName, page.Region, err)
 	}
-
-	for _, value := range locations.Results {
-		fmt.Println(" - " + value.Name)
+	if count == 0 {
+		if page.Region != "" {
+			return nil, notFound("%s command error: region %s has no location areas", commandName, page.Region)
+		}
+		return nil, notFound("%s command error: there are no location areas", commandName)
+	}
+	pages := (count + limit - 1) / limit
+	if len(names) == 0 && count > 0 {
+		return nil, args.usageError("there are only %d pages with %d areas each", pages, limit)
 	}
 
-	return nil
+	page.Offset, page.Limit, page.Count, page.Shown = offset, limit, count, true
+	cfg.Locations = page
+	seen.addLocations(names)
+
+	return locationsResult{
+		Region:    page.Region,
+		Page:      offset/limit + 1,
+		Pages:     pages,
+		Count:     count,
+		Locations: names,
+	}, nil
 }
 
-func commandBackMap(cfg *pokeapi.Config, params ...string) error {
-	locations, err := pokeapi.GetLocationAreas(cfg, pokeapi.Previous)
-	if err != nil {
-		return fmt.Errorf("map command error: %s", err)
+// getLocationAreas returns one page of location areas and how many there are in total.
+// The areas of a region are collected once and then paged through from the cache
+func getLocationAreas(ctx context.Context, cfg *pokeapi.Config, region string, offset, limit int) ([]string, int, error) {
+	if region != "" {
+		areas, err := pokeapi.GetRegionLocationAreas(ctx, cfg, region)
+		if err != nil {
+			return nil, 0, err
+		}
+		return areas[min(offset, len(areas)):min(offset+limit, len(areas))], len(areas), nil
 	}
 
+	locations, err := pokeapi.GetLocationAreas(ctx, cfg, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	names := []string{}
 	for _, value := range locations.Results {
-		fmt.Println(" - " + value.Name)
+		names = append(names, value.Name)
+	}
+	return names, locations.Count, nil
+}
+
+func locationAreaCount(ctx context.Context, cfg *pokeapi.Config, page pokeapi.LocationPage) (int, error) {
+	if page.Shown {
+		return page.Count, nil
 	}
+	_, count, err := getLocationAreas(ctx, cfg, page.Region, 0, 1)
+	return count, err
+}
 
-	return nil
+func wrapLocationsError(ctx context.Context, cfg *pokeapi.Config, commandName, region string, err error) error {
+	if region == "" {
+		return fmt.Errorf("%s command error: %w", commandName, err)
+	}
+	return wrapAPIError(ctx, cfg, commandName, "region", "region", region, err)
 }
 
-func commandCache(cfg *pokeapi.Config, params ...string) error {
-	if len(params) == 1 {
-		return errors.New("cache command error: no value provided")
+func commandCache(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	switch args.arg(0) {
+	case "stats", "clear":
+		if args.arg(1) != "" {
+			return nil, args.usageError("'%s' takes no value", args.arg(0))
+		}
+	}
+
+	switch args.arg(0) {
+	case "stats":
+		stats := cfg.Cache.Stats()
+		return cacheStatsResult{
+			Entries:      stats.Entries,
+			MaxEntries:   stats.MaxEntries,
+			Bytes:        stats.Bytes,
+			MaxBytes:     stats.MaxBytes,
+			Hits:         stats.Hits,
+			Misses:       stats.Misses,
+			Evictions:    stats.Evictions,
+			Expirations:  stats.Expirations,
+			TTL:          stats.TTL.String(),
+			ReapInterval: stats.ReapInterval.String(),
+			Persistent:   stats.Persistent,
+		}, nil
+	case "limit":
+		if args.arg(1) == "" {
+			return nil, args.usageError("no size provided for 'limit'")
+		}
+		maxBytes, err := parseSize(args.arg(1))
+		if err != nil {
+			return nil, fmt.Errorf("cache command error: %w", err)
+		}
+		cfg.Cache.SetLimits(maxBytes, cfg.Cache.Stats().MaxEntries)
+		if maxBytes == 0 {
+			return message("Cache memory is no longer limited"), nil
+		}
+		return message("Cache memory is now limited to %s", formatSize(maxBytes)), nil
+	case "clear":
+		if err := cfg.Cache.Purge(); err != nil {
+			return nil, fmt.Errorf("cache command error: %w", err)
+		}
+		return message("Cache was cleared"), nil
+	case "ttl", "reap":
+		if args.arg(1) == "" {
+			return nil, args.usageError("no duration provided for '%s'", args.arg(0))
+		}
+		duration, err := parseCacheDuration(args.arg(1))
+		if err != nil {
+			return nil, err
+		}
+
+		if args.arg(0) == "ttl" {
+			cfg.Cache.SetTTL(duration)
+			return message("Cached entries now live for %s", duration), nil
+		}
+		cfg.Cache.SetReapInterval(duration)
+		return message("Expired entries are now cleaned every %s", duration), nil
+	}
+
+	if args.arg(1) != "" {
+		return nil, args.usageError("too many arguments")
+	}
+	duration, err := parseCacheDuration(args.arg(0))
+	if err != nil {
+		return nil, err
 	}
-	inputInterval := params[1]
+	cfg.Cache.SetTTL(duration)
+	cfg.Cache.SetReapInterval(duration)
 
-	var err error
-	interval, err = strconv.Atoi(inputInterval)
+	return message("%s interval was set", duration), nil
+}
+
+// parseCacheDuration accepts Go duration syntax ("90m", "2h30m"). A bare integer is
+// treated as a number of hours, which is how the cache command used to work
+func parseCacheDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
 	if err != nil {
-		return errors.New("cache command error: interval must be an integer number")
+		hours, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, usageError("cache command error: duration must look like 30m, 2h or 1h30m")
+		}
+		duration = time.Duration(hours) * time.Hour
 	}
-	if interval <= 0 {
-		return errors.New("cache command error: the number must be greater than 0")
+
+	if duration <= 0 {
+		return 0, usageError("cache command error: the duration must be greater than 0")
 	}
+	return duration, nil
+}
 
-	fmt.Printf("%d hour interval was set\n", interval)
-	return nil
+// parseSize parses sizes like "512kb", "64mb" or "1gb". A plain number is a number of bytes
+func parseSize(value string) (int64, error) {
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10}, {"b", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSuffix(value, unit.suffix), unit.size
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, usageError("size must look like 512kb, 64mb or 1gb")
+	}
+	return size * multiplier, nil
 }
 
-func commandExplore(cfg *pokeapi.Config, params ...string) error {
-	if len(params) == 1 {
-		return errors.New("explore command error: no location provided")
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
 	}
+}
 
-	location, err := pokeapi.GetLocationArea(cfg, params[1])
+func commandExplore(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	name := args.arg(0)
+	location, err := pokeapi.GetLocationArea(ctx, cfg, name)
 	if err != nil {
-		return fmt.Errorf("explore command error: %s", err)
+		return nil, wrapAPIError(ctx, cfg, "explore", "location area", "location-area", name, err)
 	}
 
-	color.Set(color.FgBlue)
-	defer color.Unset()
+	version := args.flag("version")
+	res := exploreResult{
+		Location:    name,
+		Version:     version,
+		MethodRates: summarizeMethodRates(location, version),
+		Encounters:  summarizeEncounters(location, version),
+		Pokemon:     []string{},
+	}
+	sortEncounters(res.Encounters, args.flag("sort"))
 
-	fmt.Printf("Exploring %s...\n", params[1])
-	fmt.Println("Found Pokemon:")
-	color.Unset()
+	if limit := args.intFlag("limit", 0); limit > 0 && limit < len(res.Encounters) {
+		res.Encounters = res.Encounters[:limit]
+	}
+	for _, encounter := range res.Encounters {
+		if !slices.Contains(res.Pokemon, encounter.Pokemon) {
+			res.Pokemon = append(res.Pokemon, encounter.Pokemon)
+		}
+	}
+	seen.addPokemon(res.Pokemon)
+	return res, nil
+}
 
-	for _, value := range location.PokemonEncounters {
-		fmt.Printf(" - "+"%s"+"\n", value.Pokemon.Name)
+func commandWhere(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	name := args.arg(0)
+	encounters, err := pokeapi.GetPokemonEncounters(ctx, cfg, name)
+	if err != nil {
+		return nil, wrapAPIError(ctx, cfg, "where", "Pokémon", "pokemon", name, err)
 	}
-	return nil
+
+	version := args.flag("version")
+	res := whereResult{Pokemon: name, Version: version, Versions: groupByVersion(encounters, version)}
+
+	var areas []string
+	for _, group := range res.Versions {
+		for _, area := range group.Areas {
+			areas = append(areas, area.Area)
+		}
+	}
+	seen.addLocations(areas)
+	return res, nil
 }
 
-func commandCatch(cfg *pokeapi.Config, params ...string) error {
-	color.Set(color.FgBlue)
-	defer color.Unset()
+func commandTravel(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	name, moved := args.arg(0), true
+	if name == "" {
+		name, moved = cfg.Location, false
+	}
+	if name == "" {
+		return travelResult{Pokemon: []string{}}, nil
+	}
+
+	area, err := pokeapi.GetLocationArea(ctx, cfg, name)
+	if err != nil {
+		return nil, wrapAPIError(ctx, cfg, "travel", "location area", "location-area", name, err)
+	}
+	res := travelResult{Location: area.Name, Pokemon: areaPokemon(area), Moved: moved}
+	seen.addPokemon(res.Pokemon)
 
-	if len(params) == 1 {
-		return errors.New("catch command error: no Pokemon name provided")
+	if moved && area.Name != cfg.Location {
+		cfg.Location, cfg.Wild = area.Name, nil
+		if err := pokesave.SaveProgress(cfg); err != nil {
+			return nil, fmt.Errorf("travel command error: %w", err)
+		}
 	}
+	return res, nil
+}
 
-	if _, exists := cfg.PokemonCaught[params[1]]; exists {
-		color.Set(color.FgRed)
-		fmt.Printf("You already caught %s!\n", params[1])
-		return nil
+func commandCatch(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	name := args.arg(0)
+	if name == "" {
+		if cfg.Wild == nil {
+			return nil, args.usageError("there is no wild Pokémon around, name one or meet one with 'encounter'")
+		}
+		name = cfg.Wild.Name
+	}
+	if _, exists := cfg.PokemonCaught[name]; exists {
+		if cfg.Wild != nil && cfg.Wild.Name == name {
+			// e.g. met before it was caught by name, there is no point in keeping it around
+			cfg.Wild = nil
+			if err := pokesave.SaveProgress(cfg); err != nil {
+				return nil, fmt.Errorf("catch command error: %w", err)
+			}
+		}
+		return nil, fmt.Errorf("catch command error: %s %w", name, errAlreadyCaught)
 	}
 
-	pokemon, err := pokeapi.GetPokemon(cfg, params[1])
+	pokemon, err := pokeapi.GetPokemon(ctx, cfg, name)
 	if err != nil {
-		return fmt.Errorf("catch command error: %s", err)
+		return nil, wrapAPIError(ctx, cfg, "catch", "Pokémon", "pokemon", name, err)
+	}
+
+	rng := newRand(args)
+	ball := args.flag("ball")
+	if ball == "" {
+		ball = "poke"
 	}
+	res := catchResult{Pokemon: pokemon.Name, Ball: ballNames[ball], Found: true}
 
-	const treshold = 40
-	chance := rand.IntN(pokemon.BaseExperience) + treshold
+	health, maxHealth, status := 0, 0, ""
+	wild := cfg.Wild != nil && cfg.Wild.Name == pokemon.Name
+	if wild {
+		// the wild Pokémon is right in front of the trainer, no need to look for it
+		res.Level = cfg.Wild.Level
+		health, maxHealth, status = cfg.Wild.Health, cfg.Wild.MaxHealth, cfg.Wild.Status
+	} else if !cfg.Sandbox {
+		if res.Location, res.Chance, err = findInLocation(ctx, cfg, pokemon.Name); err != nil {
+			return nil, err
+		}
+		// rare Pokémon take more attempts to even show up
+		res.Found = rng.IntN(100) < res.Chance
+		if !res.Found {
+			return res, fmt.Errorf("catch command error: %s %w", pokemon.Name, errNotCaught)
+		}
+	}
 
-	fmt.Printf("Throwing a Pokeball at %s...\n", pokemon.Name)
+	species, err := pokeapi.GetPokemonSpecies(ctx, cfg, pokemon)
+	if err != nil {
+		return nil, fmt.Errorf("catch command error: %w", err)
+	}
+	res.CaptureRate = species.CaptureRate
 
-	if pokemon.BaseExperience > chance {
-		color.Set(color.FgRed)
-		fmt.Printf("%s escaped!\n", pokemon.Name)
-		return nil
+	res.Shakes, res.Caught = throwBall(rng, species.CaptureRate, health, maxHealth, ball, status)
+	if !res.Caught {
+		return res, fmt.Errorf("catch command error: %s %w", pokemon.Name, errNotCaught)
 	}
-	color.Set(color.FgGreen)
-	fmt.Printf("%s was caught!\n", pokemon.Name)
-	color.Set(color.FgBlue)
-	fmt.Println("You may now inspect it with the 'inspect' command.")
+
 	cfg.PokemonCaught[pokemon.Name] = pokemon
+	if wild {
+		cfg.Wild = nil
+	}
 	if err = pokesave.SaveProgress(cfg); err != nil {
-		return err
+		return nil, fmt.Errorf("catch command error: %w", err)
 	}
-	return nil
+	return res, nil
 }
 
-func commandInspect(cfg *pokeapi.Config, params ...string) error {
-	color.Set(color.FgBlue)
-	defer color.Unset()
-
-	if len(params) == 1 {
-		return errors.New("inspect command error: no Pokemon name provided")
+// findInLocation returns the area the trainer is in and the chance to meet the Pokémon there
+func findInLocation(ctx context.Context, cfg *pokeapi.Config, pokemon string) (string, int, error) {
+	area, err := currentArea(ctx, cfg, "catch")
+	if err != nil {
+		return "", 0, err
 	}
+	chance, found := encounterChance(area, pokemon)
+	if !found {
+		return "", 0, notFound("catch command error: %s doesn't live in %s. Find out where it does with 'where %s'", pokemon, area.Name, pokemon)
+	}
+	return area.Name, chance, nil
+}
 
-	if _, exists := cfg.PokemonCaught[params[1]]; !exists {
-		fmt.Println("You have not caught that pokemon!")
-		return nil
+// currentArea fetches the location area the trainer travelled to
+func currentArea(ctx context.Context, cfg *pokeapi.Config, commandName string) (pokeapi.LocationArea, error) {
+	if cfg.Location == "" {
+		return pokeapi.LocationArea{}, usageError("%s command error: you haven't travelled anywhere yet. Go to an area with 'travel {location_area}' first", commandName)
 	}
 
-	pokemon, err := pokeapi.GetPokemon(cfg, params[1])
+	area, err := pokeapi.GetLocationArea(ctx, cfg, cfg.Location)
 	if err != nil {
-		return fmt.Errorf("inspect command error: %s", err)
+		return area, wrapAPIError(ctx, cfg, commandName, "location area", "location-area", cfg.Location, err)
 	}
+	return area, nil
+}
 
-	fmt.Println(color.BlueString("Name: ") + pokemon.Name)
-	fmt.Println(color.BlueString("Height: ") + strconv.Itoa(pokemon.Height))
-	fmt.Println(color.BlueString("Weight: ") + strconv.Itoa(pokemon.Weight))
-	fmt.Println(color.BlueString("Stats: "))
+func commandEncounter(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	if wild := cfg.Wild; wild != nil {
+		return coloredMessage(color.FgYellow, "The wild %s (Lv. %d) is still here! Catch it, battle it or flee", wild.Name, wild.Level), nil
+	}
 
-	for _, value := range pokemon.Stats {
-		fmt.Printf(" - "+color.BlueString("%s: ")+"%d\n", value.Stat.Name, value.BaseStat)
+	area, err := currentArea(ctx, cfg, "encounter")
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println(color.BlueString("Types: "))
-	for _, value := range pokemon.Types {
-		fmt.Printf(" - "+"%s\n", value.Type.Name)
+	method, version := args.flag("method"), args.flag("version")
+	if method == "" {
+		method = "walk"
+	}
+	rows := summarizeEncounters(area, version)
+	available := encounterMethods(rows)
+	rows = slices.DeleteFunc(rows, func(row encounterRow) bool { return row.Method != method })
+	if len(rows) == 0 {
+		if len(available) == 0 {
+			return nil, notFound("encounter command error: no wild Pokémon live in %s", area.Name)
+		}
+		return nil, notFound("encounter command error: no Pokémon can be met with %s in %s. Try --method %s",
+			method, area.Name, strings.Join(available, ", --method "))
 	}
 
-	fmt.Println(color.BlueString("Image: "))
+	// the Pokedex holds one of each Pokémon, so the ones already caught don't show up
+	rows = slices.DeleteFunc(rows, func(row encounterRow) bool {
+		_, caught := cfg.PokemonCaught[row.Pokemon]
+		return caught
+	})
+	if len(rows) == 0 {
+		return nil, notFound("encounter command error: you already caught every Pokémon met with %s in %s", method, area.Name)
+	}
 
-	image := cfg.PokemonCaught[pokemon.Name].Image
-	if err = pokedraw.DisplayImage(image); err != nil {
-		return fmt.Errorf("display image error: %s", err)
+	row, level := pickEncounter(rows, newRand(args))
+	cfg.Wild = &pokeapi.WildPokemon{Name: row.Pokemon, Level: level, Method: method, Location: area.Name}
+	seen.addPokemon([]string{row.Pokemon})
+	if err := pokesave.SaveProgress(cfg); err != nil {
+		return nil, fmt.Errorf("encounter command error: %w", err)
 	}
-	fmt.Println()
 
-	return nil
+	return encounterResult{Location: area.Name, Method: method, Pokemon: row.Pokemon, Level: level, Chance: row.Chance}, nil
 }
 
-func commandPokedex(cfg *pokeapi.Config, params ...string) error {
-	if len(cfg.PokemonCaught) == 0 {
-		fmt.Println(color.BlueString("Your pokedex is empty! Try to catch Pokemon with 'catch' command"))
-		return nil
+func commandFlee(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	if cfg.Wild == nil {
+		return coloredMessage(color.FgBlue, "There is nothing to run away from"), nil
 	}
 
-	fmt.Println(color.BlueString("Your pokedex:"))
-	for _, value := range cfg.PokemonCaught {
-		fmt.Println(" - " + value.Name)
+	name := cfg.Wild.Name
+	cfg.Wild = nil
+	if err := pokesave.SaveProgress(cfg); err != nil {
+		return nil, fmt.Errorf("flee command error: %w", err)
 	}
+	return coloredMessage(color.FgBlue, "Got away safely from the wild %s!", name), nil
+}
 
-	return nil
+// newRand follows the --seed flag when it is given, so that the outcome can be repeated
+func newRand(args commandArgs) *rand.Rand {
+	if !args.has("seed") {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	seed := uint64(args.intFlag("seed", 0))
+	return rand.New(rand.NewPCG(seed, seed))
 }
 
-func commandBattle(cfg *pokeapi.Config, params ...string) error {
-	color.Set(color.FgBlue)
-	defer color.Unset()
+func commandInspect(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	name := args.arg(0)
+	if _, exists := cfg.PokemonCaught[name]; !exists {
+		return nil, notFound("inspect command error: you have not caught %s", name)
+	}
+
+	pokemon, err := pokeapi.GetPokemon(ctx, cfg, name)
+	if err != nil {
+		return nil, wrapAPIError(ctx, cfg, "inspect", "Pokémon", "pokemon", name, err)
+	}
 
-	if len(params) < 3 {
-		return errors.New("battle command error: no Pokemon names provided")
+	res := inspectResult{
+		Name:      pokemon.Name,
+		Height:    pokemon.Height,
+		Weight:    pokemon.Weight,
+		Stats:     []statValue{},
+		Types:     []string{},
+		ImageHash: cfg.PokemonCaught[pokemon.Name].ImageHash,
+		image:     cfg.PokemonCaught[pokemon.Name].Image,
 	}
-	if len(params) > 3 {
-		return errors.New("battle command error: wrong number of arguments. Type `help` to to see available commands")
+	for _, value := range pokemon.Stats {
+		res.Stats = append(res.Stats, statValue{Name: value.Stat.Name, Value: value.BaseStat})
+	}
+	for _, value := range pokemon.Types {
+		res.Types = append(res.Types, value.Type.Name)
 	}
 
-	_, existsFirst := cfg.PokemonCaught[params[1]]
-	_, existsSecond := cfg.PokemonCaught[params[2]]
-	if !existsFirst {
-		fmt.Printf("%s is not in your Pokedex!\n", params[1])
+	if args.has("shiny") {
+		image, err := pokeapi.GetShinyImage(ctx, cfg, pokemon)
+		if err != nil {
+			return nil, fmt.Errorf("inspect command error: %w", err)
+		}
+		res.Shiny, res.ImageHash, res.image = true, "", image
 	}
-	if !existsSecond {
-		fmt.Printf("%s is not in your Pokedex!\n", params[2])
+	return res, nil
+}
+
+func commandPokedex(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	res := pokedexResult{Pokemon: slices.Sorted(maps.Keys(cfg.PokemonCaught))}
+	if limit := args.intFlag("limit", 0); limit > 0 && limit < len(res.Pokemon) {
+		res.Pokemon = res.Pokemon[:limit]
+	}
+	return res, nil
+}
+
+func commandBattle(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	wild := args.arg(1) == ""
+	if wild && cfg.Wild == nil {
+		return nil, args.usageError("there is no wild Pokémon around, name a second Pokémon or meet one with 'encounter'")
 	}
-	if !(existsFirst && existsSecond) {
-		return nil
+
+	var missing []string
+	for _, name := range args.values {
+		if _, exists := cfg.PokemonCaught[name]; !exists {
+			missing = append(missing, name)
+		}
+	}
+	switch len(missing) {
+	case 0:
+	case 1:
+		return nil, notFound("battle command error: %s is not in your Pokedex", missing[0])
+	default:
+		return nil, notFound("battle command error: %s are not in your Pokedex", strings.Join(missing, " and "))
 	}
 
-	firstPokemon, err := pokeapi.GetPokemon(cfg, params[1])
+	firstPokemon, err := pokeapi.GetPokemon(ctx, cfg, args.arg(0))
 	if err != nil {
-		return fmt.Errorf("battle command error: %s", err)
+		return nil, wrapAPIError(ctx, cfg, "battle", "Pokémon", "pokemon", args.arg(0), err)
 	}
-	secondPokemon, err := pokeapi.GetPokemon(cfg, params[2])
+	secondName := args.arg(1)
+	if wild {
+		secondName = cfg.Wild.Name
+	}
+	secondPokemon, err := pokeapi.GetPokemon(ctx, cfg, secondName)
 	if err != nil {
-		return fmt.Errorf("battle command error: %s", err)
+		return nil, wrapAPIError(ctx, cfg, "battle", "Pokémon", "pokemon", secondName, err)
 	}
 
-	color.Set(color.FgBlue)
-	defer color.Unset()
+	firstContestant := newBattler(firstPokemon)
+	secondContestant := newBattler(secondPokemon)
+	if !wild {
+		res, err := simulateBattle(ctx, firstContestant, secondContestant, newRand(args))
+		if err != nil {
+			return nil, fmt.Errorf("battle command error: %w", err)
+		}
+		return res, nil
+	}
 
-	fmt.Printf("The %s vs %s battle has begun\n", params[1], params[2])
+	level := cfg.Wild.Level
+	secondContestant = scaleToLevel(secondContestant, level)
+	// only a wild Pokémon keeps its status after the battle, it makes it easier to catch
+	firstContestant.Inflicts = inflictsStatus(firstPokemon)
+	// a wild Pokémon that survived an earlier battle is still weakened
+	if cfg.Wild.MaxHealth > 0 {
+		secondContestant.Health, secondContestant.Status = cfg.Wild.Health, cfg.Wild.Status
+	}
+	maxHealth := max(cfg.Wild.MaxHealth, secondContestant.Health)
 
-	firstContestant := pokeapi.Battler{}
-	firstContestant.Experience = firstPokemon.BaseExperience
-	firstContestant.Name = firstPokemon.Name
-	for _, value := range firstPokemon.Stats {
+	res, err := simulateBattle(ctx, firstContestant, secondContestant, newRand(args))
+	if err != nil {
+		return nil, fmt.Errorf("battle command error: %w", err)
+	}
+	res.WildLevel = level
+	if res.Winner == firstContestant.Name {
+		cfg.Wild = nil
+	} else {
+		cfg.Wild.Health, cfg.Wild.MaxHealth, cfg.Wild.Status = res.secondHealth, maxHealth, res.secondStatus
+	}
+	if err := pokesave.SaveProgress(cfg); err != nil {
+		return nil, fmt.Errorf("battle command error: %w", err)
+	}
+	return res, nil
+}
+
+func newBattler(pokemon pokeapi.Pokemon) pokeapi.Battler {
+	battler := pokeapi.Battler{}
+	battler.Experience = pokemon.BaseExperience
+	battler.Name = pokemon.Name
+	for _, value := range pokemon.Stats {
 		switch value.Stat.Name {
 		case "hp":
-			firstContestant.Health = value.BaseStat
+			battler.Health = value.BaseStat
 		case "attack":
-			firstContestant.Attack = value.BaseStat
+			battler.Attack = value.BaseStat
 		case "defense":
-			firstContestant.Defense = value.BaseStat
+			battler.Defense = value.BaseStat
 		case "special-defense":
-			firstContestant.Parry = value.BaseStat
+			battler.Parry = value.BaseStat
 		default:
 			continue
 		}
 	}
+	return battler
+}
 
-	secondContestant := pokeapi.Battler{}
-	secondContestant.Experience = secondPokemon.BaseExperience
-	secondContestant.Name = secondPokemon.Name
-	for _, value := range secondPokemon.Stats {
-		switch value.Stat.Name {
-		case "hp":
-			secondContestant.Health = value.BaseStat
-		case "attack":
-			secondContestant.Attack = value.BaseStat
-		case "defense":
-			secondContestant.Defense = value.BaseStat
-		case "special-defense":
-			secondContestant.Parry = value.BaseStat
-		default:
-			continue
+// scaleToLevel adjusts the base stats of a wild Pokémon to its level. Pokémon from the Pokedex
+// fight with their base stats, which is roughly what they are at level 50. Even at level 1 a
+// wild Pokémon keeps half of its base stats, so low-level battles still come to an end
+func scaleToLevel(battler pokeapi.Battler, level int) pokeapi.Battler {
+	scale := func(stat int) int {
+		return max(stat*(50+level)/100, 1)
+	}
+	battler.Health = scale(battler.Health)
+	battler.Attack = scale(battler.Attack)
+	battler.Defense = scale(battler.Defense)
+	return battler
+}
+
+func commandProfile(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	if args.arg(0) == "list" {
+		if args.arg(1) != "" {
+			return nil, args.usageError("'list' takes no name")
 		}
+		profiles, err := pokesave.ListProfiles()
+		if err != nil {
+			return nil, fmt.Errorf("profile command error: %w", err)
+		}
+		if !slices.Contains(profiles, cfg.Profile) {
+			profiles = append(profiles, cfg.Profile)
+			slices.Sort(profiles)
+		}
+
+		return profilesResult{Active: cfg.Profile, Profiles: profiles}, nil
 	}
 
-	if err = startBattle(firstContestant, secondContestant); err != nil {
-		return fmt.Errorf("battle command error: failed to start battle: %s", err)
+	name := args.arg(1)
+	if name == "" {
+		return nil, args.usageError("no profile name provided for '%s'", args.arg(0))
 	}
 
-	return nil
+	switch args.arg(0) {
+	case "new":
+		if err := pokesave.CreateProfile(name); err != nil {
+			return nil, fmt.Errorf("profile command error: %w", err)
+		}
+		return message("Profile %s was created. Use 'profile switch %s' to play as it", name, name), nil
+	case "switch":
+		return switchProfile(cfg, name)
+	case "delete":
+		if name == cfg.Profile {
+			return nil, errors.New("profile command error: can't delete the active profile, switch to another one first")
+		}
+		if err := pokesave.DeleteProfile(name); err != nil {
+			return nil, fmt.Errorf("profile command error: %w", err)
+		}
+		return message("Profile %s was deleted", name), nil
+	}
+	return nil, args.usageError("unknown action '%s'", args.arg(0))
+}
+
+func switchProfile(cfg *pokeapi.Config, name string) (result, error) {
+	if name == cfg.Profile {
+		return message("You are already playing as %s", name), nil
+	}
+
+	exists, err := pokesave.ProfileExists(name)
+	if err != nil {
+		return nil, fmt.Errorf("profile command error: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("profile command error: %s: %w. Create it with 'profile new %s'", name, pokesave.ErrProfileNotFound, name)
+	}
+
+	if err := pokesave.SaveProgress(cfg); err != nil {
+		return nil, fmt.Errorf("profile command error: can't save current profile: %w", err)
+	}
+
+	// the save is loaded aside, so that a failure leaves the current profile in place
+	loaded := &pokeapi.Config{Profile: name, PokemonCaught: make(map[string]pokeapi.Pokemon)}
+	recovery, err := pokesave.LoadProgress(loaded)
+	if err != nil {
+		return nil, fmt.Errorf("profile command error: %w", err)
+	}
+	printRecovery(recovery)
+
+	cfg.Profile = loaded.Profile
+	cfg.PokemonCaught = loaded.PokemonCaught
+	cfg.SaveCreatedAt = loaded.SaveCreatedAt
+	cfg.Location, cfg.Wild = loaded.Location, loaded.Wild
+
+	if err := pokesave.SetActiveProfile(name); err != nil {
+		return nil, fmt.Errorf("profile command error: can't remember the active profile: %w", err)
+	}
+
+	return message("Switched to %s, %d Pokémon in the Pokedex", name, len(cfg.PokemonCaught)), nil
+}
+
+func commandPaths(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	describe := func(path string, err error) string {
+		if err != nil {
+			return fmt.Sprintf("unavailable (%s)", err)
+		}
+		return path
+	}
+
+	res := pathsResult{}
+	res.Data = describe(pokepaths.DataDir())
+	res.Save = describe(pokesave.SaveFilePath(cfg))
+	res.Images = describe(pokesave.AssetsDir())
+	res.Config = describe(pokepaths.ConfigDir())
+
+	cacheDir, err := pokepaths.CacheDir()
+	if err == nil && !cfg.Cache.Stats().Persistent {
+		cacheDir = "memory only"
+	} else if err == nil {
+		cacheDir = filepath.Join(cacheDir, httpCacheDir)
+	}
+	res.Cache = describe(cacheDir, err)
+	return res, nil
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeline"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokesave"
+	"github.com/fatih/color"
+)
+
+// seenNames remembers what 'map' and 'explore' have shown, so the names can be completed
+type seenNames struct {
+	locations map[string]bool
+	pokemon   map[string]bool
+}
+
+var seen = seenNames{locations: map[string]bool{}, pokemon: map[string]bool{}}
+
+func (s seenNames) addLocations(names []string) {
+	for _, name := range names {
+		s.locations[name] = true
+	}
+}
+
+func (s seenNames) addPokemon(names []string) {
+	for _, name := range names {
+		s.pokemon[name] = true
+	}
+}
+
+// newCompleter completes command names, and then the arguments of the command being typed
+func newCompleter(cfg *pokeapi.Config) pokeline.Completer {
+	return func(line string) []string {
+		fields := strings.Fields(strings.ToLower(line))
+		// the last word is the one being completed unless a space follows it
+		if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+			fields = fields[:len(fields)-1]
+		}
+		if len(fields) == 0 {
+			return slices.Collect(maps.Keys(commands))
+		}
+
+		argument := len(fields)
+		switch fields[0] {
+		case "help", "?":
+			if argument == 1 {
+				return slices.Collect(maps.Keys(commands))
+			}
+		case "map":
+			if argument == 1 {
+				return []string{"first", "last"}
+			}
+		case "explore", "travel":
+			if argument == 1 {
+				return slices.Collect(maps.Keys(seen.locations))
+			}
+		case "catch":
+			if argument == 1 {
+				return slices.Collect(maps.Keys(seen.pokemon))
+			}
+		case "where":
+			if argument == 1 {
+				return slices.Concat(slices.Collect(maps.Keys(seen.pokemon)), slices.Collect(maps.Keys(cfg.PokemonCaught)))
+			}
+		case "inspect":
+			if argument == 1 {
+				return slices.Collect(maps.Keys(cfg.PokemonCaught))
+			}
+		case "battle":
+			if argument <= 2 {
+				return slices.Collect(maps.Keys(cfg.PokemonCaught))
+			}
+		case "cache":
+			if argument == 1 {
+				return []string{"stats", "limit", "clear", "ttl", "reap"}
+			}
+		case "color":
+			if argument == 1 {
+				return []string{"on", "off"}
+			}
+		case "profile":
+			if argument == 1 {
+				return []string{"list", "new", "switch", "delete"}
+			}
+			if argument == 2 && (fields[1] == "switch" || fields[1] == "delete") {
+				profiles, _ := pokesave.ListProfiles()
+				return profiles
+			}
+		}
+		return nil
+	}
+}
+
+// loadHistory falls back to a history kept in memory when the profile's file can't be read
+func loadHistory(profile string) *pokeline.History {
+	path, err := pokesave.HistoryFilePath(profile)
+	if err != nil {
+		path = ""
+	}
+
+	history, err := pokeline.LoadHistory(path)
+	if err != nil {
+		color.Red("can't load command history: %s", err)
+	}
+	return history
+}
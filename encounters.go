@@ -0,0 +1,226 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// encounterRow is one way to meet a Pokémon in an area
+type encounterRow struct {
+	Pokemon  string   `json:"pokemon"`
+	Method   string   `json:"method"`
+	Chance   int      `json:"chance"`
+	MinLevel int      `json:"min_level"`
+	MaxLevel int      `json:"max_level"`
+	Versions []string `json:"versions"`
+}
+
+type methodRate struct {
+	Method string `json:"method"`
+	Rate   int    `json:"rate"`
+}
+
+// methodEncounter is how a Pokémon is met with one method in one version
+type methodEncounter struct {
+	method   string
+	chance   int
+	minLevel int
+	maxLevel int
+}
+
+// summarizeVersion groups the encounter details of one version by method. Chances of the same
+// method add up, e.g. for morning and night encounters
+func summarizeVersion(details pokeapi.VersionEncounterDetail) []methodEncounter {
+	methods := []methodEncounter{}
+	for _, detail := range details.EncounterDetails {
+		i := slices.IndexFunc(methods, func(m methodEncounter) bool { return m.method == detail.Method.Name })
+		if i < 0 {
+			methods = append(methods, methodEncounter{method: detail.Method.Name, minLevel: detail.MinLevel, maxLevel: detail.MaxLevel})
+			i = len(methods) - 1
+		}
+
+		method := &methods[i]
+		method.chance = min(method.chance+detail.Chance, 100)
+		method.minLevel = min(method.minLevel, detail.MinLevel)
+		method.maxLevel = max(method.maxLevel, detail.MaxLevel)
+	}
+	return methods
+}
+
+// summarizeEncounters folds the encounter details of an area into one row per Pokémon and method.
+// With a version only its encounters count, otherwise the chance is the best one across versions
+func summarizeEncounters(area pokeapi.LocationArea, version string) []encounterRow {
+	rows := []encounterRow{}
+
+	for _, encounter := range area.PokemonEncounters {
+		index := map[string]int{}
+
+		for _, details := range encounter.VersionDetails {
+			if version != "" && details.Version.Name != version {
+				continue
+			}
+
+			for _, method := range summarizeVersion(details) {
+				i, ok := index[method.method]
+				if !ok {
+					i = len(rows)
+					index[method.method] = i
+					rows = append(rows, encounterRow{
+						Pokemon:  encounter.Pokemon.Name,
+						Method:   method.method,
+						MinLevel: method.minLevel,
+						MaxLevel: method.maxLevel,
+						Versions: []string{},
+					})
+				}
+
+				row := &rows[i]
+				row.Chance = max(row.Chance, method.chance)
+				row.MinLevel = min(row.MinLevel, method.minLevel)
+				row.MaxLevel = max(row.MaxLevel, method.maxLevel)
+				row.Versions = append(row.Versions, details.Version.Name)
+			}
+		}
+	}
+	return rows
+}
+
+// whereRow is one way to meet a Pokémon in an area of a game version
+type whereRow struct {
+	Area     string `json:"area"`
+	Method   string `json:"method"`
+	Chance   int    `json:"chance"`
+	MinLevel int    `json:"min_level"`
+	MaxLevel int    `json:"max_level"`
+}
+
+type versionEncounters struct {
+	Version string     `json:"version"`
+	Areas   []whereRow `json:"areas"`
+}
+
+// groupByVersion turns the areas where a Pokémon lives into one list of areas per game version,
+// in the order the versions first appear
+func groupByVersion(encounters []pokeapi.LocationAreaEncounter, version string) []versionEncounters {
+	groups := []versionEncounters{}
+
+	for _, encounter := range encounters {
+		for _, details := range encounter.VersionDetails {
+			name := details.Version.Name
+			if version != "" && name != version {
+				continue
+			}
+
+			i := slices.IndexFunc(groups, func(group versionEncounters) bool { return group.Version == name })
+			if i < 0 {
+				groups = append(groups, versionEncounters{Version: name, Areas: []whereRow{}})
+				i = len(groups) - 1
+			}
+
+			for _, method := range summarizeVersion(details) {
+				groups[i].Areas = append(groups[i].Areas, whereRow{
+					Area:     encounter.LocationArea.Name,
+					Method:   method.method,
+					Chance:   method.chance,
+					MinLevel: method.minLevel,
+					MaxLevel: method.maxLevel,
+				})
+			}
+		}
+	}
+	return groups
+}
+
+// areaPokemon returns the Pokémon that can be met in the area, in the PokeAPI order
+func areaPokemon(area pokeapi.LocationArea) []string {
+	names := []string{}
+	for _, encounter := range area.PokemonEncounters {
+		names = append(names, encounter.Pokemon.Name)
+	}
+	return names
+}
+
+// encounterChance returns the best chance to meet the Pokémon in the area across all
+// methods and versions, or false when it doesn't live there
+func encounterChance(area pokeapi.LocationArea, pokemon string) (int, bool) {
+	chance, found := 0, false
+	for _, row := range summarizeEncounters(area, "") {
+		if row.Pokemon == pokemon {
+			chance, found = max(chance, row.Chance), true
+		}
+	}
+	return chance, found
+}
+
+// pickEncounter chooses one of the rows weighted by its chance and a level within its range
+func pickEncounter(rows []encounterRow, rng *rand.Rand) (encounterRow, int) {
+	total := 0
+	for _, row := range rows {
+		total += max(row.Chance, 1)
+	}
+
+	roll := rng.IntN(total)
+	picked := rows[len(rows)-1]
+	for _, row := range rows {
+		if roll < max(row.Chance, 1) {
+			picked = row
+			break
+		}
+		roll -= max(row.Chance, 1)
+	}
+	return picked, picked.MinLevel + rng.IntN(max(picked.MaxLevel-picked.MinLevel, 0)+1)
+}
+
+// encounterMethods returns the methods Pokémon can be met with in the rows
+func encounterMethods(rows []encounterRow) []string {
+	methods := []string{}
+	for _, row := range rows {
+		if !slices.Contains(methods, row.Method) {
+			methods = append(methods, row.Method)
+		}
+	}
+	return methods
+}
+
+// summarizeMethodRates returns how often each encounter method triggers in the area,
+// the best rate across versions unless a version is given
+func summarizeMethodRates(area pokeapi.LocationArea, version string) []methodRate {
+	rates := []methodRate{}
+	for _, method := range area.EncounterMethodRates {
+		rate, found := 0, false
+		for _, details := range method.VersionDetails {
+			if version == "" || details.Version.Name == version {
+				rate, found = max(rate, details.Rate), true
+			}
+		}
+		if found {
+			rates = append(rates, methodRate{Method: method.EncounterMethod.Name, Rate: rate})
+		}
+	}
+	return rates
+}
+
+// sortEncounters orders rows by rarity (the rarest first) or by name, keeping the PokeAPI order otherwise
+func sortEncounters(rows []encounterRow, order string) {
+	switch order {
+	case "rarity":
+		slices.SortStableFunc(rows, func(a, b encounterRow) int {
+			return cmp.Compare(a.Chance, b.Chance)
+		})
+	case "name":
+		slices.SortStableFunc(rows, func(a, b encounterRow) int {
+			return cmp.Compare(a.Pokemon, b.Pokemon)
+		})
+	}
+}
+
+func formatLevels(minLevel, maxLevel int) string {
+	if minLevel == maxLevel {
+		return fmt.Sprint(minLevel)
+	}
+	return fmt.Sprintf("%d-%d", minLevel, maxLevel)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// testArea builds a location area from PokeAPI JSON, which is shorter than the nested structs
+func testArea(t *testing.T, data string) pokeapi.LocationArea {
+	t.Helper()
+	area := pokeapi.LocationArea{}
+	if err := json.Unmarshal([]byte(data), &area); err != nil {
+		t.Fatal(err)
+	}
+	return area
+}
+
+const testAreaJSON = `{
+	"name": "test-area",
+	"pokemon_encounters": [
+		{
+			"pokemon": {"name": "zubat"},
+			"version_details": [
+				{
+					"version": {"name": "diamond"},
+					"encounter_details": [
+						{"chance": 30, "min_level": 3, "max_level": 5, "method": {"name": "walk"}},
+						{"chance": 20, "min_level": 2, "max_level": 4, "method": {"name": "walk"}}
+					]
+				},
+				{
+					"version": {"name": "pearl"},
+					"encounter_details": [
+						{"chance": 60, "min_level": 6, "max_level": 6, "method": {"name": "walk"}}
+					]
+				}
+			]
+		},
+		{
+			"pokemon": {"name": "magikarp"},
+			"version_details": [
+				{
+					"version": {"name": "diamond"},
+					"encounter_details": [
+						{"chance": 70, "min_level": 5, "max_level": 5, "method": {"name": "old-rod"}},
+						{"chance": 60, "min_level": 5, "max_level": 5, "method": {"name": "old-rod"}},
+						{"chance": 10, "min_level": 10, "max_level": 20, "method": {"name": "surf"}}
+					]
+				}
+			]
+		}
+	]
+}`
+
+func TestSummarizeEncounters(t *testing.T) {
+	area := testArea(t, testAreaJSON)
+
+	tests := []struct {
+		name    string
+		version string
+		want    []encounterRow
+	}{
+		{
+			name: "all versions take the best chance",
+			want: []encounterRow{
+				{Pokemon: "zubat", Method: "walk", Chance: 60, MinLevel: 2, MaxLevel: 6, Versions: []string{"diamond", "pearl"}},
+				{Pokemon: "magikarp", Method: "old-rod", Chance: 100, MinLevel: 5, MaxLevel: 5, Versions: []string{"diamond"}},
+				{Pokemon: "magikarp", Method: "surf", Chance: 10, MinLevel: 10, MaxLevel: 20, Versions: []string{"diamond"}},
+			},
+		},
+		{
+			name:    "chances of one method add up within a version",
+			version: "diamond",
+			want: []encounterRow{
+				{Pokemon: "zubat", Method: "walk", Chance: 50, MinLevel: 2, MaxLevel: 5, Versions: []string{"diamond"}},
+				{Pokemon: "magikarp", Method: "old-rod", Chance: 100, MinLevel: 5, MaxLevel: 5, Versions: []string{"diamond"}},
+				{Pokemon: "magikarp", Method: "surf", Chance: 10, MinLevel: 10, MaxLevel: 20, Versions: []string{"diamond"}},
+			},
+		},
+		{
+			name:    "other versions are left out",
+			version: "pearl",
+			want: []encounterRow{
+				{Pokemon: "zubat", Method: "walk", Chance: 60, MinLevel: 6, MaxLevel: 6, Versions: []string{"pearl"}},
+			},
+		},
+		{
+			name:    "unknown version",
+			version: "red",
+			want:    []encounterRow{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := summarizeEncounters(area, test.version)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("summarizeEncounters() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSortEncounters(t *testing.T) {
+	rows := summarizeEncounters(testArea(t, testAreaJSON), "")
+
+	sortEncounters(rows, "rarity")
+	if got := []int{rows[0].Chance, rows[1].Chance, rows[2].Chance}; !reflect.DeepEqual(got, []int{10, 60, 100}) {
+		t.Errorf("chances sorted by rarity = %v, want [10 60 100]", got)
+	}
+
+	sortEncounters(rows, "name")
+	if got := []string{rows[0].Pokemon, rows[1].Pokemon, rows[2].Pokemon}; !reflect.DeepEqual(got, []string{"magikarp", "magikarp", "zubat"}) {
+		t.Errorf("Pokémon sorted by name = %v", got)
+	}
+}
+
+func TestPickEncounter(t *testing.T) {
+	rows := []encounterRow{
+		{Pokemon: "zubat", Chance: 60, MinLevel: 2, MaxLevel: 6},
+		{Pokemon: "geodude", Chance: 30, MinLevel: 8, MaxLevel: 8},
+		{Pokemon: "onix", Chance: 10, MinLevel: 10, MaxLevel: 12},
+		// rows without a chance can still be met, just rarely
+		{Pokemon: "mewtwo", Chance: 0, MinLevel: 70, MaxLevel: 70},
+	}
+
+	const picks = 10000
+	counts := map[string]int{}
+	for seed := range uint64(picks) {
+		row, level := pickEncounter(rows, rand.New(rand.NewPCG(seed, seed)))
+		if level < row.MinLevel || level > row.MaxLevel {
+			t.Fatalf("seed %d: %s at level %d, want %d to %d", seed, row.Pokemon, level, row.MinLevel, row.MaxLevel)
+		}
+		counts[row.Pokemon]++
+	}
+
+	// weights are the chances, with 1 for rows that have none
+	total := 101.0
+	for _, row := range rows {
+		want := float64(max(row.Chance, 1)) / total
+		if got := float64(counts[row.Pokemon]) / picks; math.Abs(got-want) > 0.02 {
+			t.Errorf("%s picked %.3f of the time, want about %.3f", row.Pokemon, got, want)
+		}
+	}
+	if counts["mewtwo"] == 0 {
+		t.Error("a row without a chance was never picked")
+	}
+}
+
+func TestPickEncounterLevels(t *testing.T) {
+	rows := []encounterRow{{Pokemon: "zubat", Chance: 100, MinLevel: 2, MaxLevel: 4}}
+
+	levels := map[int]bool{}
+	for seed := range uint64(100) {
+		_, level := pickEncounter(rows, rand.New(rand.NewPCG(seed, seed)))
+		levels[level] = true
+	}
+	if !reflect.DeepEqual(levels, map[int]bool{2: true, 3: true, 4: true}) {
+		t.Errorf("levels = %v, want 2, 3 and 4", levels)
+	}
+}
@@ -1,85 +1,185 @@
 package pokeapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokecache"
+)
+
+const (
+	DefaultBaseURL    = "https://pokeapi.co/api/v2/"
+	DefaultUserAgent  = "go-pokedex-cli"
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 3
+	// DefaultPageSize is how many location areas map shows at once unless told otherwise
+	DefaultPageSize = 20
 )
 
-func GetLocationArea(cfg *Config, location string) (locationArea LocationArea, err error) {
-	url := "https://pokeapi.co/api/v2/location-area/" + location
+// Client holds everything needed to talk to a PokeAPI instance. SpriteHost, when set,
+// replaces the scheme and host of sprite URLs returned by the API (e.g. for a local mirror)
+type Client struct {
+	BaseURL    string
+	SpriteHost string
+	UserAgent  string
+	HTTPClient *http.Client
+	// Timeout limits a single attempt, MaxRetries is the number of attempts after the first one
+	Timeout    time.Duration
+	MaxRetries int
+
+	inflight *flightGroup
+}
+
+func NewClient(baseURL, spriteHost, userAgent string) Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return Client{
+		BaseURL:    baseURL,
+		SpriteHost: strings.TrimSuffix(spriteHost, "/"),
+		UserAgent:  userAgent,
+		HTTPClient: &http.Client{},
+		Timeout:    DefaultTimeout,
+		MaxRetries: DefaultMaxRetries,
+		inflight:   newFlightGroup(),
+	}
+}
+
+func (c Client) endpoint(path string) string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL + path
+	}
+	return c.BaseURL + path
+}
+
+func (c Client) spriteURL(rawURL string) string {
+	if c.SpriteHost == "" {
+		return rawURL
+	}
+
+	sprite, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	host, err := url.Parse(c.SpriteHost)
+	if err != nil || host.Host == "" {
+		return rawURL
+	}
+	sprite.Scheme, sprite.Host = host.Scheme, host.Host
+	return sprite.String()
+}
+
+// get fetches url, sharing the response with concurrent callers that ask for the same url.
+// The response is added to the cache once, by the request that actually hit the network
+func (c Client) get(ctx context.Context, cache *pokecache.Cache, url string) ([]byte, error) {
+	fetch := func(ctx context.Context) ([]byte, error) {
+		bodyData, err := c.fetch(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		cache.Add(url, bodyData)
+		return bodyData, nil
+	}
+
+	if c.inflight == nil {
+		return fetch(ctx)
+	}
+	return c.inflight.do(ctx, url, fetch)
+}
+
+func GetLocationArea(ctx context.Context, cfg *Config, location string) (locationArea LocationArea, err error) {
+	url := cfg.Client.endpoint("location-area/" + location)
 	locationArea = LocationArea{}
 
 	if data, exists := cfg.Cache.Get(url); exists {
 		if err = json.Unmarshal(data, &locationArea); err != nil {
-			return locationArea, fmt.Errorf("error decoding cached data: %s", err)
+			return locationArea, fmt.Errorf("error decoding cached data: %w: %w", ErrDecode, err)
 		}
 		return locationArea, nil
 	}
 
-	if err := makeAPICall(url, &locationArea, cfg); err != nil {
+	if err := makeAPICall(ctx, url, &locationArea, cfg); err != nil {
 		return locationArea, err
 	}
 
 	return locationArea, nil
 }
 
-func GetLocationAreas(cfg *Config, direction Direction) (locations LocationAreasResponse, err error) {
-	url := "https://pokeapi.co/api/v2/location-area/?offset=0&limit=20"
+// regionWorkers limits how many locations of a region are requested at the same time
+const regionWorkers = 8
+
+func GetLocationAreas(ctx context.Context, cfg *Config, offset, limit int) (locations LocationAreasResponse, err error) {
+	url := cfg.Client.endpoint(fmt.Sprintf("location-area/?offset=%d&limit=%d", offset, limit))
 	locations = LocationAreasResponse{}
 
-	// pagination(direction) rules for the very first ever request
-	if cfg.NextURL == nil && cfg.PreviousURL == nil {
-		if direction == Previous {
-			return locations, fmt.Errorf("no more locations")
-		}
-		if err := makeAPICall(url, &locations, cfg); err != nil {
-			return locations, err
-		}
-		cfg.NextURL, cfg.PreviousURL = locations.Next, locations.Previous
-		return locations, nil
+	if err := getCached(ctx, cfg, url, &locations); err != nil {
+		return locations, err
 	}
+	return locations, nil
+}
 
-	// pagination(direction) rules for the next requests
-	switch direction {
-	case Next:
-		if cfg.NextURL == nil {
-			return locations, fmt.Errorf("no more locations")
-		}
-		url = *cfg.NextURL
-	case Previous:
-		if cfg.PreviousURL == nil {
-			return locations, fmt.Errorf("no more locations")
-		}
-		url = *cfg.PreviousURL
+// GetRegionLocationAreas walks the region -> location -> location area hierarchy and returns
+// the names of all areas of a region, in the order PokeAPI lists its locations
+func GetRegionLocationAreas(ctx context.Context, cfg *Config, regionName string) ([]string, error) {
+	region := Region{}
+	if err := getCached(ctx, cfg, cfg.Client.endpoint("region/"+regionName), &region); err != nil {
+		return nil, err
 	}
 
-	if data, exists := cfg.Cache.Get(url); exists {
-		if err = json.Unmarshal(data, &locations); err != nil {
-			return locations, fmt.Errorf("error decoding cached data: %s", err)
-		}
-		cfg.NextURL, cfg.PreviousURL = locations.Next, locations.Previous
-		return locations, nil
-	}
+	areas := make([][]string, len(region.Locations))
+	errs := make([]error, len(region.Locations))
+	workers := make(chan struct{}, regionWorkers)
+	var wg sync.WaitGroup
 
-	if err := makeAPICall(url, &locations, cfg); err != nil {
-		return locations, err
-	}
+	for i, value := range region.Locations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
 
-	cfg.NextURL, cfg.PreviousURL = locations.Next, locations.Previous
+			location := Location{}
+			if err := getCached(ctx, cfg, cfg.Client.endpoint("location/"+value.Name), &location); err != nil {
+				errs[i] = err
+				return
+			}
+			for _, area := range location.Areas {
+				areas[i] = append(areas[i], area.Name)
+			}
+		}()
+	}
+	wg.Wait()
 
-	return locations, nil
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.Concat(areas...), nil
 }
 
-func GetPokemon(cfg *Config, pokemonName string) (pokemon Pokemon, err error) {
-	url := "https://pokeapi.co/api/v2/pokemon/" + pokemonName
+func GetPokemon(ctx context.Context, cfg *Config, pokemonName string) (pokemon Pokemon, err error) {
+	url := cfg.Client.endpoint("pokemon/" + pokemonName)
 	pokemon = Pokemon{}
 
 	// is exists in cache
 	if data, exists := cfg.Cache.Get(url); exists {
 		if err = json.Unmarshal(data, &pokemon); err != nil {
-			return pokemon, fmt.Errorf("error decoding cached data: %s", err)
+			return pokemon, fmt.Errorf("error decoding cached data: %w: %w", ErrDecode, err)
 		}
 		return pokemon, nil
 	}
@@ -89,11 +189,11 @@ func GetPokemon(cfg *Config, pokemonName string) (pokemon Pokemon, err error) {
 		return pokemonData, nil
 	}
 
-	if err = makeAPICall(url, &pokemon, cfg); err != nil {
+	if err = makeAPICall(ctx, url, &pokemon, cfg); err != nil {
 		return pokemon, err
 	}
 
-	image, err := getImage(cfg, pokemon.Sprites.Other.OfficialArtwork.FrontDefault)
+	image, err := getImage(ctx, cfg, cfg.Client.spriteURL(pokemon.Sprites.Other.OfficialArtwork.FrontDefault))
 	if err != nil {
 		return pokemon, err
 	}
@@ -102,65 +202,91 @@ func GetPokemon(cfg *Config, pokemonName string) (pokemon Pokemon, err error) {
 	// we need to marshall pokemon image data so that we can add it to cache(data in cache is stored in json []byte)
 	pokemonData, err := json.Marshal(pokemon)
 	if err != nil {
-		return pokemon, err
+		return pokemon, fmt.Errorf("error encoding pokemon data: %w", err)
 	}
 	cfg.Cache.Add(url, pokemonData)
 
 	return pokemon, nil
 }
 
-func getImage(cfg *Config, url string) (image []byte, err error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("can't initialize request for server: %s", err)
-	}
+// GetResourceNames returns the names of all entries of a named PokeAPI resource, e.g. "pokemon"
+func GetResourceNames(ctx context.Context, cfg *Config, resource string) (names []string, err error) {
+	url := cfg.Client.endpoint(resource + "/?offset=0&limit=100000")
+	list := NamedResourceList{}
 
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("can't send request to server: %s", err)
+	if data, exists := cfg.Cache.Get(url); exists {
+		if err = json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("error decoding cached data: %w: %w", ErrDecode, err)
+		}
+	} else if err = makeAPICall(ctx, url, &list, cfg); err != nil {
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("non-OK HTTP status: %s", res.Status)
+	names = make([]string, 0, len(list.Results))
+	for _, value := range list.Results {
+		names = append(names, value.Name)
 	}
+	return names, nil
+}
 
-	bodyData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %s", err)
+// GetPokemonEncounters returns the areas where a Pokémon can be met. They are fetched
+// directly, without the Pokémon and its artwork
+func GetPokemonEncounters(ctx context.Context, cfg *Config, pokemonName string) ([]LocationAreaEncounter, error) {
+	encounters := []LocationAreaEncounter{}
+	if err := getCached(ctx, cfg, cfg.Client.endpoint("pokemon/"+pokemonName+"/encounters"), &encounters); err != nil {
+		return nil, err
 	}
+	return encounters, nil
+}
 
-	cfg.Cache.Add(url, bodyData)
+// GetPokemonSpecies fetches the species of a Pokémon. Forms like deoxys-attack share
+// the species of deoxys
+func GetPokemonSpecies(ctx context.Context, cfg *Config, pokemon Pokemon) (PokemonSpecies, error) {
+	name := pokemon.Species.Name
+	if name == "" {
+		name = pokemon.Name
+	}
 
-	return bodyData, nil
+	species := PokemonSpecies{}
+	if err := getCached(ctx, cfg, cfg.Client.endpoint("pokemon-species/"+name), &species); err != nil {
+		return species, err
+	}
+	return species, nil
 }
 
-func makeAPICall[T any](url string, target *T, cfg *Config) error {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("can't initialize request for server: %s", err)
+// GetShinyImage downloads the shiny artwork of a Pokémon. It isn't kept in the Pokedex,
+// only in the cache
+func GetShinyImage(ctx context.Context, cfg *Config, pokemon Pokemon) ([]byte, error) {
+	url := pokemon.Sprites.Other.OfficialArtwork.FrontShiny
+	if url == "" {
+		return nil, fmt.Errorf("%s has no shiny artwork", pokemon.Name)
 	}
+	return getImage(ctx, cfg, cfg.Client.spriteURL(url))
+}
 
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("can't send request to server: %s", err)
-	}
-	defer res.Body.Close()
+func getImage(ctx context.Context, cfg *Config, url string) (image []byte, err error) {
+	return cfg.Client.get(ctx, cfg.Cache, url)
+}
 
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("non-OK HTTP status: %s", res.Status)
+// getCached decodes the cached response for url, or requests it when it isn't cached
+func getCached[T any](ctx context.Context, cfg *Config, url string, target *T) error {
+	if data, exists := cfg.Cache.Get(url); exists {
+		if err := json.Unmarshal(data, target); err != nil {
+			return fmt.Errorf("error decoding cached data: %w: %w", ErrDecode, err)
+		}
+		return nil
 	}
+	return makeAPICall(ctx, url, target, cfg)
+}
 
-	bodyData, err := io.ReadAll(res.Body)
+func makeAPICall[T any](ctx context.Context, url string, target *T, cfg *Config) error {
+	bodyData, err := cfg.Client.get(ctx, cfg.Cache, url)
 	if err != nil {
-		return fmt.Errorf("error reading response body: %s", err)
+		return err
 	}
 
-	cfg.Cache.Add(url, bodyData)
 	if err = json.Unmarshal(bodyData, target); err != nil {
-		return fmt.Errorf("error decoding response body: %s", err)
+		return fmt.Errorf("error decoding response body: %w: %w", ErrDecode, err)
 	}
 
 	return nil
@@ -0,0 +1,288 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokecache"
+)
+
+// testConfig returns a config that talks to server and caches for the length of the test
+func testConfig(t *testing.T, server *httptest.Server) *Config {
+	t.Helper()
+	cache := pokecache.NewCache(time.Minute)
+	t.Cleanup(cache.Close)
+
+	client := NewClient(server.URL, "", "")
+	client.HTTPClient = server.Client()
+	return &Config{Client: client, Cache: cache}
+}
+
+// statusSequence answers with the status codes in order, then with 200 and body
+func statusSequence(body string, statuses ...int) (http.HandlerFunc, *atomic.Int32) {
+	requests := &atomic.Int32{}
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte(body))
+	}, requests
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantErr      error
+		wantRequests int32
+	}{
+		{"ok", nil, 3, nil, 1},
+		{"server error is retried", []int{http.StatusServiceUnavailable, http.StatusBadGateway}, 3, nil, 3},
+		{"retries run out", []int{500, 500, 500}, 2, ErrUpstream, 3},
+		{"not found is not retried", []int{http.StatusNotFound}, 3, ErrNotFound, 1},
+		{"bad request is not retried", []int{http.StatusBadRequest}, 3, ErrUpstream, 1},
+		{"no retries", []int{http.StatusServiceUnavailable}, 0, ErrUpstream, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, requests := statusSequence(`{"name": "pikachu"}`, test.statuses...)
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			client := NewClient(server.URL, "", "")
+			client.MaxRetries = test.maxRetries
+			body, err := client.fetch(context.Background(), server.URL)
+
+			if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+				t.Fatalf("fetch() error = %v, want %v", err, test.wantErr)
+			}
+			if err == nil && string(body) != `{"name": "pikachu"}` {
+				t.Errorf("fetch() = %q", body)
+			}
+			if got := requests.Load(); got != test.wantRequests {
+				t.Errorf("%d requests, want %d", got, test.wantRequests)
+			}
+		})
+	}
+}
+
+func TestFetchRetryAfter(t *testing.T) {
+	handler, requests := statusSequence("ok", http.StatusTooManyRequests)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	start := time.Now()
+	body, err := NewClient(server.URL, "", "").fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if string(body) != "ok" || requests.Load() != 2 {
+		t.Errorf("fetch() = %q after %d requests, want \"ok\" after 2", body, requests.Load())
+	}
+	// the backoff alone would wait well under a second
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want Retry-After of 1s to be respected", elapsed)
+	}
+}
+
+func TestFetchRetryAfterTooLong(t *testing.T) {
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", "").fetch(context.Background(), server.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("fetch() error = %v, want a rate limit StatusError", err)
+	}
+	if statusErr.RetryAfter != time.Hour {
+		t.Errorf("RetryAfter = %s, want 1h", statusErr.RetryAfter)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("%d requests, want 1, waiting an hour is not worth it", requests.Load())
+	}
+}
+
+func TestFetchCanceledWhileWaiting(t *testing.T) {
+	handler, _ := statusSequence("ok", http.StatusTooManyRequests)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := NewClient(server.URL, "", "").fetch(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("fetch() error = %v, want the deadline and the last attempt's error", err)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr error
+	}{
+		{"not found", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}, ErrNotFound},
+		{"rate limited", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, ErrRateLimited},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, ErrUpstream},
+		{"not JSON", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>"))
+		}, ErrDecode},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+
+			cfg := testConfig(t, server)
+			cfg.Client.MaxRetries = 0
+			_, err := GetLocationArea(context.Background(), cfg, "canalave-city-area")
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("GetLocationArea() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIErrorNetwork(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	cfg := testConfig(t, server)
+	cfg.Client.MaxRetries = 0
+	server.Close()
+
+	_, err := GetLocationArea(context.Background(), cfg, "canalave-city-area")
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("GetLocationArea() error = %v, want %v", err, ErrNetwork)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 2 ", 2 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for _, test := range tests {
+		if got := parseRetryAfter(test.value); got != test.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, want about a minute", future, got)
+	}
+}
+
+func TestGetSharesConcurrentRequests(t *testing.T) {
+	const callers = 10
+
+	requests := &atomic.Int32{}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write([]byte("pikachu"))
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t, server)
+	url := cfg.Client.endpoint("pokemon/pikachu")
+
+	var started, done sync.WaitGroup
+	bodies := make([]string, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			body, err := cfg.Client.get(context.Background(), cfg.Cache, url)
+			bodies[i], errs[i] = string(body), err
+		}()
+	}
+	started.Wait()
+	// give every caller the time to join the request before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	for i := range callers {
+		if errs[i] != nil || bodies[i] != "pikachu" {
+			t.Errorf("caller %d: get() = %q, %v", i, bodies[i], errs[i])
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+	if stats := cfg.Cache.Stats(); stats.Entries != 1 {
+		t.Errorf("cache has %d entries, want 1", stats.Entries)
+	}
+}
+
+func TestGetCallerGivesUp(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("pikachu"))
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t, server)
+	url := cfg.Client.endpoint("pokemon/pikachu")
+
+	result := make(chan error)
+	go func() {
+		body, err := cfg.Client.get(context.Background(), cfg.Cache, url)
+		if err == nil && string(body) != "pikachu" {
+			err = errors.New("unexpected body " + string(body))
+		}
+		result <- err
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cfg.Client.get(ctx, cfg.Cache, url); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled get() error = %v, want %v", err, context.Canceled)
+	}
+
+	// the other caller still gets the response
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("get() error = %v", err)
+	}
+}
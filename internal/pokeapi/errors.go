@@ -0,0 +1,81 @@
+package pokeapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrRateLimited = errors.New("rate limited")
+	ErrUpstream    = errors.New("upstream error")
+	ErrDecode      = errors.New("decode error")
+	ErrNetwork     = errors.New("network error")
+
+	ErrNoMoreLocations = errors.New("no more locations")
+)
+
+// StatusError describes a non-OK response from PokeAPI. It unwraps to ErrNotFound,
+// ErrRateLimited or ErrUpstream depending on the status code, so callers can use errors.Is
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.StatusCode == http.StatusTooManyRequests && e.RetryAfter > 0 {
+		return fmt.Sprintf("non-OK HTTP status: %s (retry after %s)", e.Status, e.RetryAfter)
+	}
+	return fmt.Sprintf("non-OK HTTP status: %s", e.Status)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	default:
+		return ErrUpstream
+	}
+}
+
+func newStatusError(res *http.Response) *StatusError {
+	statusErr := &StatusError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+	if res.Request != nil {
+		statusErr.URL = res.Request.URL.String()
+	}
+	return statusErr
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: delay in seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
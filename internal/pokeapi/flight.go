@@ -0,0 +1,50 @@
+package pokeapi
+
+import (
+	"context"
+	"sync"
+)
+
+type flightCall struct {
+	done chan struct{}
+	val  []byte
+	err  error
+}
+
+// flightGroup collapses concurrent fetches of the same URL into a single request.
+// The request runs detached from the callers' contexts, so one caller giving up
+// doesn't fail the others. Each caller still stops waiting when its own context is done
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flightCall)}
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	call, exists := g.calls[key]
+	if !exists {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+
+		go func() {
+			call.val, call.err = fn(context.WithoutCancel(ctx))
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+		return call.val, call.err
+	}
+}
@@ -0,0 +1,115 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	baseBackoff   = 250 * time.Millisecond
+	maxBackoff    = 8 * time.Second
+	maxRetryAfter = 30 * time.Second
+)
+
+// fetch performs a GET request and returns the body of a 200 response. Each attempt is limited
+// by c.Timeout. 5xx, 429 and dropped connections are retried up to c.MaxRetries times
+func (c Client) fetch(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+
+		if attempt >= c.MaxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return nil, err
+		}
+
+		wait := backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > wait {
+			if statusErr.RetryAfter > maxRetryAfter {
+				return nil, err
+			}
+			wait = statusErr.RetryAfter
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (c Client) fetchOnce(ctx context.Context, url string) ([]byte, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't initialize request for server: %w", err)
+	}
+
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't send request to server: %w: %w", ErrNetwork, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	bodyData, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w: %w", ErrNetwork, err)
+	}
+	return bodyData, nil
+}
+
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	// a single attempt that ran into c.Timeout is worth repeating
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// backoff returns an exponentially growing delay with jitter in [d/2, d)
+func backoff(attempt int) time.Duration {
+	delay := baseBackoff << attempt
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay/2 + rand.N(delay/2)
+}
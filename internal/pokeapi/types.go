@@ -1,14 +1,45 @@
 package pokeapi
 
 import (
+	"time"
+
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokecache"
 )
 
 type Config struct {
-	NextURL       *string
-	PreviousURL   *string
-	Cache         pokecache.Cache
+	Client        Client
+	Locations     LocationPage
+	Cache         *pokecache.Cache
 	PokemonCaught map[string]Pokemon
+	Profile       string
+	SaveCreatedAt time.Time
+	// Location is the location area the trainer is in, Pokémon can only be caught there
+	Location string
+	// Sandbox lets the trainer catch any Pokémon from anywhere
+	Sandbox bool
+	// Wild is the wild Pokémon the trainer has met, nil when there is none
+	Wild *WildPokemon
+}
+
+// WildPokemon is a Pokémon met with the encounter command. It stays until it is caught,
+// defeated or the trainer flees, also between runs
+type WildPokemon struct {
+	Name     string `json:"name"`
+	Level    int    `json:"level"`
+	Method   string `json:"method"`
+	Location string `json:"location"`
+	// Health and MaxHealth stay zero until a battle, which means full health
+	Health    int    `json:"health,omitempty"`
+	MaxHealth int    `json:"max_health,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+// PokemonSpecies holds what all forms of a Pokémon share
+type PokemonSpecies struct {
+	Name        string `json:"name"`
+	CaptureRate int    `json:"capture_rate"`
+	IsLegendary bool   `json:"is_legendary"`
+	IsMythical  bool   `json:"is_mythical"`
 }
 
 type Battler struct {
@@ -18,6 +49,20 @@ type Battler struct {
 	Parry      int
 	Experience int
 	Name       string
+	// Inflicts is the status its hits may cause, Status is the one it suffers from
+	Inflicts string
+	Status   string
+}
+
+// LocationPage is the page of location areas map and mapb have shown last
+type LocationPage struct {
+	// Region limits the pages to the areas of one region, empty means all areas
+	Region string
+	Offset int
+	// Limit is the page size, it is kept for the following pages
+	Limit int
+	Count int
+	Shown bool
 }
 
 type Direction int
@@ -37,6 +82,32 @@ type LocationAreasResponse struct {
 	} `json:"results"`
 }
 
+type NamedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type Region struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Locations []NamedResource `json:"locations"`
+}
+
+type Location struct {
+	ID     int             `json:"id"`
+	Name   string          `json:"name"`
+	Region NamedResource   `json:"region"`
+	Areas  []NamedResource `json:"areas"`
+}
+
+type NamedResourceList struct {
+	Count   int `json:"count"`
+	Results []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"results"`
+}
+
 type LocationArea struct {
 	EncounterMethodRates []struct {
 		EncounterMethod struct {
@@ -70,28 +141,39 @@ type LocationArea struct {
 			Name string `json:"name"`
 			URL  string `json:"url"`
 		} `json:"pokemon"`
-		VersionDetails []struct {
-			EncounterDetails []struct {
-				Chance          int   `json:"chance"`
-				ConditionValues []any `json:"condition_values"`
-				MaxLevel        int   `json:"max_level"`
-				Method          struct {
-					Name string `json:"name"`
-					URL  string `json:"url"`
-				} `json:"method"`
-				MinLevel int `json:"min_level"`
-			} `json:"encounter_details"`
-			MaxChance int `json:"max_chance"`
-			Version   struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
+		VersionDetails []VersionEncounterDetail `json:"version_details"`
 	} `json:"pokemon_encounters"`
 }
 
+// VersionEncounterDetail lists the ways to meet a Pokémon in one game version
+type VersionEncounterDetail struct {
+	EncounterDetails []struct {
+		Chance          int   `json:"chance"`
+		ConditionValues []any `json:"condition_values"`
+		MaxLevel        int   `json:"max_level"`
+		Method          struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"method"`
+		MinLevel int `json:"min_level"`
+	} `json:"encounter_details"`
+	MaxChance int `json:"max_chance"`
+	Version   struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"version"`
+}
+
+// LocationAreaEncounter is an area where a Pokémon can be met, from /pokemon/{name}/encounters
+type LocationAreaEncounter struct {
+	LocationArea   NamedResource            `json:"location_area"`
+	VersionDetails []VersionEncounterDetail `json:"version_details"`
+}
+
 type Pokemon struct {
-	Image     []byte
+	// Image is only kept in memory and in the cache. Saves reference it by ImageHash
+	Image     []byte `json:"Image,omitempty"`
+	ImageHash string `json:"image_hash,omitempty"`
 	Abilities []struct {
 		Ability struct {
 			Name string `json:"name"`
@@ -344,20 +426,24 @@ type Pokemon struct {
 			} `json:"generation-viii"`
 		} `json:"versions"`
 	} `json:"sprites"`
-	Stats []struct {
-		BaseStat int `json:"base_stat"`
-		Effort   int `json:"effort"`
-		Stat     struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"stat"`
-	} `json:"stats"`
-	Types []struct {
-		Slot int `json:"slot"`
-		Type struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"type"`
-	} `json:"types"`
-	Weight int `json:"weight"`
+	Stats  []PokemonStat `json:"stats"`
+	Types  []PokemonType `json:"types"`
+	Weight int           `json:"weight"`
+}
+
+type PokemonStat struct {
+	BaseStat int `json:"base_stat"`
+	Effort   int `json:"effort"`
+	Stat     struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"stat"`
+}
+
+type PokemonType struct {
+	Slot int `json:"slot"`
+	Type struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"type"`
 }
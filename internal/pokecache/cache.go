@@ -1,6 +1,7 @@
 package pokecache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
@@ -10,54 +11,248 @@ type cacheEntry struct {
 	val       []byte
 }
 
+type lruItem struct {
+	key   string
+	entry cacheEntry
+}
+
+// Cache keeps entries in memory in least recently used order. When MaxBytes or MaxEntries
+// is exceeded the least recently used entries are evicted. Zero limits mean no limit
 type Cache struct {
-	mu   *sync.Mutex
-	data map[string]cacheEntry
+	mu           sync.Mutex
+	data         map[string]*list.Element
+	order        *list.List
+	ttl          time.Duration
+	reapInterval time.Duration
+	maxBytes     int64
+	maxEntries   int
+	disk         *diskStore
+
+	bytes       int64
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	resetReaper chan time.Duration
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// Stats is a snapshot of the cache state and its counters since start
+type Stats struct {
+	Entries      int
+	Bytes        int64
+	MaxEntries   int
+	MaxBytes     int64
+	Hits         uint64
+	Misses       uint64
+	Evictions    uint64
+	Expirations  uint64
+	TTL          time.Duration
+	ReapInterval time.Duration
+	Persistent   bool
 }
 
 func (c *Cache) Add(key string, value []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data[key] = cacheEntry{
+	entry := cacheEntry{
 		createdAt: time.Now().UTC(),
 		val:       value,
 	}
+	c.store(key, entry)
 
+	if c.disk != nil {
+		// the disk tier is best effort, the entry is still served from memory
+		c.disk.add(key, entry)
+	}
 }
 
 func (c *Cache) Get(key string) (cacheData []byte, exists bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	value, exists := c.data[key]
-	if !exists {
-		return nil, exists
+	if element, exists := c.data[key]; exists {
+		item := element.Value.(*lruItem)
+		if time.Since(item.entry.createdAt) <= c.ttl {
+			c.order.MoveToFront(element)
+			c.hits++
+			return item.entry.val, true
+		}
+		c.remove(element)
+		c.expirations++
+	}
+
+	if c.disk != nil {
+		if entry, exists := c.disk.get(key, c.ttl); exists {
+			c.store(key, entry)
+			c.hits++
+			return entry.val, true
+		}
+	}
+
+	c.misses++
+	return nil, false
+}
+
+// SetTTL changes how long entries stay valid. It applies to entries that are already cached
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+}
+
+// SetReapInterval changes how often expired entries are removed
+func (c *Cache) SetReapInterval(interval time.Duration) {
+	c.mu.Lock()
+	c.reapInterval = interval
+	c.mu.Unlock()
+
+	select {
+	case c.resetReaper <- interval:
+	case <-c.done:
 	}
-	return value.val, true
 }
 
-func NewCache(interval time.Duration) Cache {
-	c := Cache{
-		mu:   &sync.Mutex{},
-		data: make(map[string]cacheEntry),
+// SetLimits changes the memory budget and evicts entries that no longer fit
+func (c *Cache) SetLimits(maxBytes int64, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxBytes, c.maxEntries = maxBytes, maxEntries
+	c.evict()
+}
+
+// Purge removes every entry from memory and disk
+func (c *Cache) Purge() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.data)
+	c.order.Init()
+	c.bytes = 0
+	if c.disk != nil {
+		return c.disk.purge()
 	}
+	return nil
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Entries:      len(c.data),
+		Bytes:        c.bytes,
+		MaxEntries:   c.maxEntries,
+		MaxBytes:     c.maxBytes,
+		Hits:         c.hits,
+		Misses:       c.misses,
+		Evictions:    c.evictions,
+		Expirations:  c.expirations,
+		TTL:          c.ttl,
+		ReapInterval: c.reapInterval,
+		Persistent:   c.disk != nil,
+	}
+}
+
+// Close stops the reaper. The cache itself stays usable, expired entries are just not reaped anymore
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func NewCache(interval time.Duration) *Cache {
+	c := newCache(interval)
 	go c.reapLoop(interval)
 	return c
 }
 
+// NewDiskCache returns a cache that also persists entries in dir, so they survive restarts.
+// Entries on disk expire after the same interval as the ones in memory
+func NewDiskCache(interval time.Duration, dir string) (*Cache, error) {
+	disk, err := newDiskStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newCache(interval)
+	c.disk = disk
+	go c.reapLoop(interval)
+	return c, nil
+}
+
+func newCache(interval time.Duration) *Cache {
+	return &Cache{
+		data:         make(map[string]*list.Element),
+		order:        list.New(),
+		ttl:          interval,
+		reapInterval: interval,
+		resetReaper:  make(chan time.Duration),
+		done:         make(chan struct{}),
+	}
+}
+
+// store must be called with c.mu held
+func (c *Cache) store(key string, entry cacheEntry) {
+	if element, exists := c.data[key]; exists {
+		c.remove(element)
+	}
+
+	c.data[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	c.bytes += int64(len(entry.val))
+	c.evict()
+}
+
+// remove must be called with c.mu held
+func (c *Cache) remove(element *list.Element) {
+	item := c.order.Remove(element).(*lruItem)
+	delete(c.data, item.key)
+	c.bytes -= int64(len(item.entry.val))
+}
+
+// evict drops least recently used entries until the cache fits its limits.
+// The most recent entry is always kept, even if it alone exceeds MaxBytes
+func (c *Cache) evict() {
+	for c.order.Len() > 1 &&
+		((c.maxBytes > 0 && c.bytes > c.maxBytes) || (c.maxEntries > 0 && c.order.Len() > c.maxEntries)) {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
 func (c *Cache) reapLoop(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mu.Lock()
-		for key, entry := range c.data {
-			if time.Since(entry.createdAt) > interval {
-				delete(c.data, key)
-			}
+	for {
+		select {
+		case <-c.done:
+			return
+		case interval := <-c.resetReaper:
+			ticker.Reset(interval)
+		case <-ticker.C:
+			c.reap()
 		}
-		c.mu.Unlock()
 	}
+}
 
+func (c *Cache) reap() {
+	c.mu.Lock()
+	ttl := c.ttl
+	for _, element := range c.data {
+		if time.Since(element.Value.(*lruItem).entry.createdAt) > ttl {
+			c.remove(element)
+			c.expirations++
+		}
+	}
+	c.mu.Unlock()
+
+	if c.disk != nil {
+		c.disk.reap(ttl)
+	}
 }
@@ -0,0 +1,244 @@
+package pokecache
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// keys lists the cached keys from the most to the least recently used
+func keys(c *Cache) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := []string{}
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*lruItem).key)
+	}
+	return keys
+}
+
+func TestEviction(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxBytes      int64
+		maxEntries    int
+		steps         func(c *Cache)
+		wantKeys      []string
+		wantBytes     int64
+		wantEvictions uint64
+	}{
+		{
+			name: "no limits",
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Add("c", []byte("3333"))
+			},
+			wantKeys:  []string{"c", "b", "a"},
+			wantBytes: 12,
+		},
+		{
+			name:       "entry limit",
+			maxEntries: 2,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Add("c", []byte("3333"))
+			},
+			wantKeys:      []string{"c", "b"},
+			wantBytes:     8,
+			wantEvictions: 1,
+		},
+		{
+			name:     "byte limit",
+			maxBytes: 10,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Add("c", []byte("3333"))
+			},
+			wantKeys:      []string{"c", "b"},
+			wantBytes:     8,
+			wantEvictions: 1,
+		},
+		{
+			name:       "get makes an entry recently used",
+			maxEntries: 2,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Get("a")
+				c.Add("c", []byte("3333"))
+			},
+			wantKeys:      []string{"c", "a"},
+			wantBytes:     8,
+			wantEvictions: 1,
+		},
+		{
+			name:     "an entry larger than the limit is kept alone",
+			maxBytes: 10,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("this is too big"))
+			},
+			wantKeys:      []string{"b"},
+			wantBytes:     15,
+			wantEvictions: 1,
+		},
+		{
+			name:       "replacing an entry counts its new size",
+			maxEntries: 2,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Add("a", []byte("11"))
+			},
+			wantKeys:  []string{"a", "b"},
+			wantBytes: 6,
+		},
+		{
+			name: "lower limits evict right away",
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Add("c", []byte("3333"))
+				c.SetLimits(5, 0)
+			},
+			wantKeys:      []string{"c"},
+			wantBytes:     4,
+			wantEvictions: 2,
+		},
+		{
+			name:       "purge",
+			maxEntries: 2,
+			steps: func(c *Cache) {
+				c.Add("a", []byte("1111"))
+				c.Add("b", []byte("2222"))
+				c.Purge()
+			},
+			wantKeys: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCache(time.Minute)
+			c.SetLimits(test.maxBytes, test.maxEntries)
+			test.steps(c)
+
+			if got := keys(c); !reflect.DeepEqual(got, test.wantKeys) {
+				t.Errorf("keys = %v, want %v", got, test.wantKeys)
+			}
+			stats := c.Stats()
+			if stats.Bytes != test.wantBytes || stats.Entries != len(test.wantKeys) {
+				t.Errorf("%d entries of %d bytes, want %d of %d", stats.Entries, stats.Bytes, len(test.wantKeys), test.wantBytes)
+			}
+			if stats.Evictions != test.wantEvictions {
+				t.Errorf("%d evictions, want %d", stats.Evictions, test.wantEvictions)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := newCache(time.Minute)
+	c.Add("a", []byte("1111"))
+
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.SetTTL(0)
+	// an expired entry is removed and counts as a miss
+	if _, exists := c.Get("a"); exists {
+		t.Error("Get() returned an expired entry")
+	}
+
+	want := Stats{Hits: 2, Misses: 2, Expirations: 1, ReapInterval: time.Minute}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReapIntervalReset(t *testing.T) {
+	c := NewCache(time.Hour)
+	defer c.Close()
+
+	c.Add("a", []byte("1111"))
+	c.SetTTL(time.Millisecond)
+	// with the hourly ticker the entry would stay until the next hour
+	c.SetReapInterval(10 * time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Entries > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the expired entry wasn't reaped after the interval changed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := c.Stats(); stats.Expirations != 1 || stats.ReapInterval != 10*time.Millisecond {
+		t.Errorf("Stats() = %+v, want 1 expiration and a 10ms reap interval", stats)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := NewDiskCache(time.Minute, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	first.Add("https://pokeapi.co/api/v2/pokemon/pikachu", []byte("pikachu"))
+
+	// a new instance, like the next run of the CLI, finds the entry on disk
+	second, err := NewDiskCache(time.Minute, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	data, exists := second.Get("https://pokeapi.co/api/v2/pokemon/pikachu")
+	if !exists || string(data) != "pikachu" {
+		t.Fatalf("Get() = %q, %v, want the entry from disk", data, exists)
+	}
+	if stats := second.Stats(); stats.Hits != 1 || stats.Entries != 1 || !stats.Persistent {
+		t.Errorf("Stats() = %+v, want a hit loaded into memory", stats)
+	}
+
+	if err := second.Purge(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("%d files left after Purge()", len(files))
+	}
+}
+
+func TestDiskCacheExpired(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := NewDiskCache(time.Minute, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Add("a", []byte("1111"))
+
+	other, err := NewDiskCache(time.Minute, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	other.SetTTL(0)
+
+	if _, exists := other.Get("a"); exists {
+		t.Error("Get() returned an expired entry from disk")
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d files left, want the expired entry removed", len(files))
+	}
+}
@@ -0,0 +1,111 @@
+package pokecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// diskStore keeps one file per cache key. Files are written to a temp file and renamed
+// into place, so several CLI instances can share the directory without seeing partial entries.
+// The modification time of a file is the creation time of its entry
+type diskStore struct {
+	dir string
+}
+
+func newDiskStore(dir string) (*diskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &diskStore{dir: dir}, nil
+}
+
+func (d *diskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *diskStore) add(key string, entry cacheEntry) error {
+	tmp, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(entry.val); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chtimes(tmp.Name(), entry.createdAt, entry.createdAt); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+func (d *diskStore) get(key string, ttl time.Duration) (entry cacheEntry, exists bool) {
+	path := d.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	if time.Since(info.ModTime()) > ttl {
+		os.Remove(path)
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	return cacheEntry{createdAt: info.ModTime().UTC(), val: data}, true
+}
+
+// reap removes expired entries and temp files left behind by crashed writers.
+// Files that another instance removes concurrently are simply skipped
+func (d *diskStore) reap(ttl time.Duration) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+
+	for _, dirEntry := range entries {
+		if dirEntry.IsDir() {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		maxAge := ttl
+		if strings.HasPrefix(dirEntry.Name(), ".tmp-") {
+			maxAge = time.Hour
+		}
+		if time.Since(info.ModTime()) > maxAge {
+			os.Remove(filepath.Join(d.dir, dirEntry.Name()))
+		}
+	}
+}
+
+func (d *diskStore) purge() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, dirEntry := range entries {
+		if dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".tmp-") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.dir, dirEntry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package pokeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl+C is pressed
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the words that can stand at the cursor. line is the text before the cursor,
+// the editor keeps only the words that start with what was already typed
+type Completer func(line string) []string
+
+// Editor reads lines with cursor movement, history, reverse search and tab completion.
+// When the input is not a terminal it reads plain lines
+type Editor struct {
+	History  *History
+	Complete Completer
+
+	in     *os.File
+	out    io.Writer
+	reader *bufio.Reader
+
+	mu  sync.Mutex
+	raw *terminalState
+}
+
+func NewEditor(in *os.File, out io.Writer) *Editor {
+	return &Editor{in: in, out: out, reader: bufio.NewReader(in)}
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyNewline   = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// escape sequences are mapped past the last valid rune
+const (
+	keyUnknown = unicode.MaxRune + 1 + iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+)
+
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+
+	// historyIndex equals the history length while the new line is edited
+	historyIndex int
+	edited       []rune
+	tabs         int
+}
+
+// ReadLine shows the prompt and returns the entered line without the line break.
+// It returns io.EOF on Ctrl+D at an empty line and ErrInterrupted on Ctrl+C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	if !isTerminal(fd) {
+		return e.readPlain(prompt)
+	}
+
+	state, err := makeRaw(fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	e.mu.Lock()
+	e.raw = state
+	e.mu.Unlock()
+	defer e.Restore()
+
+	l := &lineState{prompt: prompt, historyIndex: e.historyLen()}
+	l.refresh(e.out)
+
+	var pending rune
+	for {
+		key := pending
+		pending = 0
+		if key == 0 {
+			if key, err = e.readKey(); err != nil {
+				return "", err
+			}
+		}
+		if key != keyTab {
+			l.tabs = 0
+		}
+
+		switch key {
+		case keyEnter, keyNewline:
+			fmt.Fprint(e.out, "\n")
+			return string(l.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			l.deleteRune()
+		case keyBackspace, keyCtrlH:
+			if l.pos > 0 {
+				l.pos--
+				l.deleteRune()
+			}
+		case keyDelete:
+			l.deleteRune()
+		case keyLeft, keyCtrlB:
+			l.pos = max(l.pos-1, 0)
+		case keyRight, keyCtrlF:
+			l.pos = min(l.pos+1, len(l.buf))
+		case keyHome, keyCtrlA:
+			l.pos = 0
+		case keyEnd, keyCtrlE:
+			l.pos = len(l.buf)
+		case keyUp, keyCtrlP:
+			e.moveInHistory(l, -1)
+		case keyDown, keyCtrlN:
+			e.moveInHistory(l, 1)
+		case keyCtrlK:
+			l.buf = l.buf[:l.pos]
+		case keyCtrlU:
+			l.buf = slices.Delete(l.buf, 0, l.pos)
+			l.pos = 0
+		case keyCtrlW:
+			start := l.pos
+			for start > 0 && l.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && l.buf[start-1] != ' ' {
+				start--
+			}
+			l.buf = slices.Delete(l.buf, start, l.pos)
+			l.pos = start
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyTab:
+			e.complete(l)
+		case keyCtrlR:
+			accepted, next, err := e.reverseSearch(l)
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				fmt.Fprint(e.out, "\n")
+				return string(l.buf), nil
+			}
+			pending = next
+		default:
+			if key < keyUnknown && unicode.IsPrint(key) {
+				l.buf = slices.Insert(l.buf, l.pos, key)
+				l.pos++
+			}
+		}
+		l.refresh(e.out)
+	}
+}
+
+// Restore leaves raw mode if a line is being read. It is safe to call from another goroutine,
+// e.g. before the program exits on a signal
+func (e *Editor) Restore() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.raw != nil {
+		restore(int(e.in.Fd()), e.raw)
+		e.raw = nil
+	}
+}
+
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (e *Editor) readKey() (rune, error) {
+	key, _, err := e.reader.ReadRune()
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+	// a terminal sends an escape sequence at once, a lone Esc is a key press of its own
+	if e.reader.Buffered() == 0 {
+		return keyEscape, nil
+	}
+
+	kind, err := e.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if kind != '[' && kind != 'O' {
+		return keyUnknown, nil
+	}
+
+	// the sequence ends with a byte from '@' to '~', e.g. "[A" or "[3~"
+	var sequence []byte
+	for {
+		b, err := e.reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		sequence = append(sequence, b)
+		if b >= '@' && b <= '~' {
+			break
+		}
+	}
+
+	switch string(sequence) {
+	case "A":
+		return keyUp, nil
+	case "B":
+		return keyDown, nil
+	case "C":
+		return keyRight, nil
+	case "D":
+		return keyLeft, nil
+	case "H", "1~", "7~":
+		return keyHome, nil
+	case "F", "4~", "8~":
+		return keyEnd, nil
+	case "3~":
+		return keyDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+func (e *Editor) historyLen() int {
+	if e.History == nil {
+		return 0
+	}
+	return e.History.Len()
+}
+
+func (e *Editor) moveInHistory(l *lineState, delta int) {
+	index := l.historyIndex + delta
+	if index < 0 || index > e.historyLen() {
+		return
+	}
+
+	if l.historyIndex == e.historyLen() {
+		l.edited = slices.Clone(l.buf)
+	}
+	l.historyIndex = index
+
+	if index == e.historyLen() {
+		l.buf = slices.Clone(l.edited)
+	} else {
+		l.buf = []rune(e.History.Line(index))
+	}
+	l.pos = len(l.buf)
+}
+
+// complete replaces the word at the cursor with the only candidate or with the prefix all
+// candidates share. Pressing Tab again lists the candidates
+func (e *Editor) complete(l *lineState) {
+	if e.Complete == nil {
+		return
+	}
+
+	start := l.pos
+	for start > 0 && l.buf[start-1] != ' ' {
+		start--
+	}
+	word := string(l.buf[start:l.pos])
+
+	var candidates []string
+	for _, candidate := range e.Complete(string(l.buf[:l.pos])) {
+		if strings.HasPrefix(candidate, word) && !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	slices.Sort(candidates)
+
+	replace := func(text string) {
+		l.buf = slices.Concat(l.buf[:start], []rune(text), l.buf[l.pos:])
+		l.pos = start + len([]rune(text))
+	}
+
+	switch {
+	case len(candidates) == 0:
+		fmt.Fprint(e.out, "\a")
+	case len(candidates) == 1:
+		replace(candidates[0] + " ")
+	default:
+		if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+			replace(prefix)
+		} else if l.tabs > 0 {
+			fmt.Fprint(e.out, "\n"+formatColumns(candidates))
+		} else {
+			fmt.Fprint(e.out, "\a")
+		}
+	}
+	l.tabs++
+}
+
+// reverseSearch looks through the history for lines that contain the typed text, like Ctrl+R
+// in a shell. Enter runs the match, Ctrl+G gives up and any other key starts editing the match
+func (e *Editor) reverseSearch(l *lineState) (accepted bool, next rune, err error) {
+	original, originalPos := slices.Clone(l.buf), l.pos
+	query := ""
+	match := -1
+
+	search := func(from int) bool {
+		for i := min(from, e.historyLen()-1); i >= 0; i-- {
+			if strings.Contains(e.History.Line(i), query) {
+				match = i
+				return true
+			}
+		}
+		return false
+	}
+
+	found := true
+	for {
+		label := "reverse-i-search"
+		if !found {
+			label = "failing " + label
+		}
+		text := ""
+		if match >= 0 {
+			text = e.History.Line(match)
+		}
+		fmt.Fprintf(e.out, "\r(%s)`%s': %s\x1b[K", label, query, text)
+
+		key, err := e.readKey()
+		if err != nil {
+			return false, 0, err
+		}
+
+		switch {
+		case key == keyCtrlR:
+			if match >= 0 {
+				found = search(match - 1)
+			}
+		case key == keyBackspace || key == keyCtrlH:
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				found = search(e.historyLen() - 1)
+			}
+		case key == keyCtrlG || key == keyCtrlC:
+			l.buf, l.pos = original, originalPos
+			return false, 0, nil
+		case key < keyUnknown && unicode.IsPrint(key):
+			query += string(key)
+			if match < 0 {
+				found = search(e.historyLen() - 1)
+			} else {
+				found = search(match)
+			}
+		default:
+			if match >= 0 {
+				l.buf = []rune(e.History.Line(match))
+				l.pos = len(l.buf)
+				l.historyIndex = match
+			}
+			if key == keyEnter || key == keyNewline {
+				return true, 0, nil
+			}
+			return false, key, nil
+		}
+	}
+}
+
+func (l *lineState) deleteRune() {
+	if l.pos < len(l.buf) {
+		l.buf = slices.Delete(l.buf, l.pos, l.pos+1)
+	}
+}
+
+// refresh redraws the prompt and the line and puts the cursor back in place
+func (l *lineState) refresh(out io.Writer) {
+	fmt.Fprintf(out, "\r%s%s\x1b[K", l.prompt, string(l.buf))
+	if tail := len(l.buf) - l.pos; tail > 0 {
+		fmt.Fprintf(out, "\x1b[%dD", tail)
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
+
+func formatColumns(words []string) string {
+	const width = 80
+
+	longest := 0
+	for _, word := range words {
+		longest = max(longest, len(word))
+	}
+	perRow := max(width/(longest+2), 1)
+
+	var builder strings.Builder
+	for i, word := range words {
+		builder.WriteString(word)
+		if (i+1)%perRow == 0 || i == len(words)-1 {
+			builder.WriteString("\n")
+		} else {
+			builder.WriteString(strings.Repeat(" ", longest+2-len(word)))
+		}
+	}
+	return builder.String()
+}
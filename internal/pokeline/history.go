@@ -0,0 +1,104 @@
+package pokeline
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxHistory is how many lines are kept, older ones are dropped when the file is loaded
+const MaxHistory = 1000
+
+// History keeps entered lines in memory and appends them to a file
+type History struct {
+	path  string
+	lines []string
+}
+
+// LoadHistory reads the history file at path. A missing file is an empty history,
+// an empty path keeps the history in memory only
+func LoadHistory(path string) (*History, error) {
+	history := &History{path: path}
+	if path == "" {
+		return history, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	defer file.Close()
+
+	total := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.lines = append(history.lines, line)
+			total++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return history, err
+	}
+
+	if len(history.lines) > MaxHistory {
+		history.lines = history.lines[len(history.lines)-MaxHistory:]
+	}
+	// the file only grows while appending, so it is shortened once it's far over the limit
+	if total > 2*MaxHistory {
+		return history, history.rewrite()
+	}
+	return history, nil
+}
+
+// Add remembers a line unless it repeats the previous one
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return nil
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > MaxHistory {
+		h.lines = h.lines[1:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Len returns the number of remembered lines
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+// Line returns the i-th line, the oldest one is 0
+func (h *History) Line(i int) string {
+	return h.lines[i]
+}
+
+func (h *History) rewrite() error {
+	temp := h.path + ".tmp"
+	if err := os.WriteFile(temp, []byte(strings.Join(h.lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, h.path)
+}
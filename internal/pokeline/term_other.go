@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package pokeline
+
+import "errors"
+
+// other systems read plain lines without editing
+type terminalState struct{}
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (*terminalState, error) {
+	return nil, errors.New("raw terminal mode is not supported on this system")
+}
+
+func restore(fd int, state *terminalState) error {
+	return nil
+}
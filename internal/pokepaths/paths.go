@@ -0,0 +1,77 @@
+package pokepaths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "go-pokedex-cli"
+
+// xdgDir returns the directory an XDG variable points to. Relative paths are invalid
+// according to the spec and ignored
+func xdgDir(variable string) (string, bool) {
+	dir := os.Getenv(variable)
+	if !filepath.IsAbs(dir) {
+		return "", false
+	}
+	return filepath.Join(dir, appName), true
+}
+
+// DataDir returns the directory for user data such as saves. It honors XDG_DATA_HOME
+// and otherwise follows the platform convention
+func DataDir() (string, error) {
+	if dir, ok := xdgDir("XDG_DATA_HOME"); ok {
+		return dir, nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+		return filepath.Join(dir, appName), nil
+	case "darwin", "ios":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, appName), nil
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share", appName), nil
+	}
+}
+
+// ConfigDir returns the directory for settings. It honors XDG_CONFIG_HOME on every platform,
+// os.UserConfigDir only does so on Unix systems other than macOS
+func ConfigDir() (string, error) {
+	if dir, ok := xdgDir("XDG_CONFIG_HOME"); ok {
+		return dir, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// CacheDir returns the directory for data that can be downloaded again.
+// Like ConfigDir it honors XDG_CACHE_HOME on every platform
+func CacheDir() (string, error) {
+	if dir, ok := xdgDir("XDG_CACHE_HOME"); ok {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
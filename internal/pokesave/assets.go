@@ -0,0 +1,66 @@
+package pokesave
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokepaths"
+)
+
+const assetsDir = "assets"
+
+// storeAsset writes data to the content-addressed asset directory and returns its hash.
+// Identical images are stored only once
+func storeAsset(data []byte) (hash string, err error) {
+	sum := sha256.Sum256(data)
+	hash = hex.EncodeToString(sum[:])
+	path, err := assetPath(hash)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("can't create assets directory: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", fmt.Errorf("can't store asset: %w", err)
+	}
+	return hash, nil
+}
+
+func loadAsset(hash string) ([]byte, error) {
+	path, err := assetPath(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't load asset %s: %w", hash, err)
+	}
+	return data, nil
+}
+
+// AssetsDir is shared by all profiles, so the same artwork is stored once
+func AssetsDir() (string, error) {
+	dir, err := pokepaths.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("can't locate data directory: %w", err)
+	}
+	return filepath.Join(dir, assetsDir), nil
+}
+
+func assetPath(hash string) (string, error) {
+	dir, err := AssetsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hash+".png"), nil
+}
@@ -0,0 +1,104 @@
+package pokesave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxBackups is how many previous versions of the save are kept as pokedex.json.bak.1..N
+const maxBackups = 3
+
+// writeFileAtomic writes data to a temp file in the same directory, syncs it
+// and renames it over path, so readers see either the old or the new content
+func writeFileAtomic(path string, data []byte) error {
+	tmpName, err := writeTemp(path, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpName)
+
+	if err = os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// writeFileWithBackups atomically replaces path and keeps its previous
+// content as the newest of maxBackups rotating backups
+func writeFileWithBackups(path string, data []byte) error {
+	tmpName, err := writeTemp(path, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpName)
+
+	if err = rotateBackups(path); err != nil {
+		return fmt.Errorf("can't rotate backups: %w", err)
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// writeTemp writes data to a synced temp file next to path and returns its name
+func writeTemp(path string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// rotateBackups shifts path.bak.1..N-1 one step down and links path to path.bak.1.
+// path itself stays in place until the new save is renamed over it
+func rotateBackups(path string) error {
+	for i := maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.Link(path, backupPath(path, 1)); err == nil {
+		return nil
+	}
+	// some file systems have no hard links
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(backupPath(path, 1), data)
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// syncDir makes the rename durable. Not every platform supports syncing
+// a directory, so errors are ignored
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
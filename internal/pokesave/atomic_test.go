@@ -0,0 +1,41 @@
+package pokesave
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestWriteFileWithBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+
+	for i := 1; i <= 5; i++ {
+		if err := writeFileWithBackups(path, []byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+		// the live save never goes missing between two writes
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	want := map[string]string{
+		path:                "5",
+		backupPath(path, 1): "4",
+		backupPath(path, 2): "3",
+		backupPath(path, 3): "2",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, content)
+		}
+	}
+	if _, err := os.Stat(backupPath(path, maxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("more than %d backups are kept", maxBackups)
+	}
+}
@@ -0,0 +1,38 @@
+package pokesave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// MigrateLegacySaves imports a save from legacyDir, where older versions kept it relative
+// to the working directory, into the default profile. Nothing happens if there is no legacy
+// save or the default profile already has one. It returns the path of the imported save
+func MigrateLegacySaves(legacyDir string) (migratedTo string, err error) {
+	legacyData, err := os.ReadFile(filepath.Join(legacyDir, saveFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	target, err := SaveFilePath(&pokeapi.Config{Profile: DefaultProfile})
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(target); err == nil {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(target, legacyData); err != nil {
+		return "", fmt.Errorf("can't write imported save: %w", err)
+	}
+	return target, nil
+}
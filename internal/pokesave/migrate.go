@@ -0,0 +1,124 @@
+package pokesave
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// migration upgrades raw save data from one format version to the next
+type migration func(data []byte) ([]byte, error)
+
+// migrations[i] upgrades a save from version i+1 to version i+2
+var migrations = []migration{
+	migrateBareMapToEnvelope,
+	migrateToSavedPokemon,
+}
+
+// saveFileV2 is the envelope of version 2, which kept the Pokémon as PokeAPI returned them
+type saveFileV2 struct {
+	Version    int                        `json:"version"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	UpdatedAt  time.Time                  `json:"updated_at"`
+	AppVersion string                     `json:"app_version"`
+	Location   string                     `json:"location,omitempty"`
+	Wild       *pokeapi.WildPokemon       `json:"wild,omitempty"`
+	Pokedex    map[string]pokeapi.Pokemon `json:"pokedex"`
+}
+
+// saveVersion detects the format version of raw save data. Version 1 predates the
+// envelope and is a bare map of Pokémon names to Pokémon
+func saveVersion(data []byte) (int, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return 0, err
+	}
+
+	rawVersion, exists := probe["version"]
+	if !exists || bytes.HasPrefix(bytes.TrimSpace(rawVersion), []byte("{")) {
+		// a bare map may legitimately contain a Pokémon called "version"
+		return 1, nil
+	}
+
+	var version int
+	if err := json.Unmarshal(rawVersion, &version); err != nil {
+		return 0, fmt.Errorf("invalid save version: %w", err)
+	}
+	return version, nil
+}
+
+// migrate runs the migration chain until data is in the current format.
+// It reports whether anything had to be changed
+func migrate(data []byte) (migrated []byte, changed bool, err error) {
+	version, err := saveVersion(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	if version > CurrentVersion {
+		return nil, false, fmt.Errorf("save format version %d is newer than supported version %d", version, CurrentVersion)
+	}
+
+	for ; version < CurrentVersion; version++ {
+		if data, err = migrations[version-1](data); err != nil {
+			return nil, false, fmt.Errorf("can't migrate save from version %d: %w", version, err)
+		}
+		changed = true
+	}
+	return data, changed, nil
+}
+
+// migrateBareMapToEnvelope wraps a version 1 save in the envelope and moves
+// embedded images to the asset directory
+func migrateBareMapToEnvelope(data []byte) ([]byte, error) {
+	pokedex := make(map[string]pokeapi.Pokemon)
+	if err := json.Unmarshal(data, &pokedex); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	for name, pokemon := range pokedex {
+		if len(pokemon.Image) == 0 {
+			continue
+		}
+		hash, err := storeAsset(pokemon.Image)
+		if err != nil {
+			return nil, err
+		}
+		pokemon.Image, pokemon.ImageHash = nil, hash
+		pokedex[name] = pokemon
+	}
+
+	now := time.Now().UTC()
+	return json.Marshal(saveFileV2{
+		Version:    2,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		AppVersion: appVersion(),
+		Pokedex:    pokedex,
+	})
+}
+
+// migrateToSavedPokemon keeps only the fields of each Pokémon the app uses
+func migrateToSavedPokemon(data []byte) ([]byte, error) {
+	old := saveFileV2{}
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	pokedex := make(map[string]savedPokemon, len(old.Pokedex))
+	for name, pokemon := range old.Pokedex {
+		pokedex[name] = newSavedPokemon(pokemon)
+	}
+
+	return json.Marshal(saveFile{
+		Version:    3,
+		CreatedAt:  old.CreatedAt,
+		UpdatedAt:  old.UpdatedAt,
+		AppVersion: old.AppVersion,
+		Location:   old.Location,
+		Wild:       old.Wild,
+		Pokedex:    pokedex,
+	})
+}
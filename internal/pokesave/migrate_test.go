@@ -0,0 +1,204 @@
+package pokesave
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSaveVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{"bare map", `{"pikachu": {"name": "pikachu"}}`, 1, false},
+		{"empty bare map", `{}`, 1, false},
+		{"bare map with a Pokémon called version", `{"version": {"name": "version"}}`, 1, false},
+		{"envelope", `{"version": 2, "pokedex": {}}`, 2, false},
+		{"envelope with saved Pokémon", `{"version": 3, "pokedex": {}}`, 3, false},
+		{"newer envelope", `{"version": 7}`, 7, false},
+		{"version is not a number", `{"version": "two"}`, 0, true},
+		{"not JSON", `{broken`, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := saveVersion([]byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("saveVersion() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("saveVersion() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMigrateBareMap(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	image := []byte("not really a png")
+	legacy, err := json.Marshal(map[string]any{
+		"pikachu":   map[string]any{"name": "pikachu", "Image": image},
+		"bulbasaur": map[string]any{"name": "bulbasaur"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, changed, err := migrate(legacy)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if !changed {
+		t.Error("migrate() reported no change for a version 1 save")
+	}
+
+	save := saveFile{}
+	if err := json.Unmarshal(data, &save); err != nil {
+		t.Fatal(err)
+	}
+	if save.Version != CurrentVersion {
+		t.Errorf("version = %d, want %d", save.Version, CurrentVersion)
+	}
+	if len(save.Pokedex) != 2 {
+		t.Fatalf("pokedex has %d Pokémon, want 2", len(save.Pokedex))
+	}
+
+	pikachu := save.Pokedex["pikachu"]
+	if bytes.Contains(data, []byte(`"Image"`)) || pikachu.ImageHash == "" {
+		t.Fatalf("image was not moved to the assets: hash %q in %s", pikachu.ImageHash, data)
+	}
+	stored, err := loadAsset(pikachu.ImageHash)
+	if err != nil || !bytes.Equal(stored, image) {
+		t.Errorf("loadAsset() = %q, %v, want the original image", stored, err)
+	}
+	if hash := save.Pokedex["bulbasaur"].ImageHash; hash != "" {
+		t.Errorf("Pokémon without an image got asset %q", hash)
+	}
+}
+
+func TestMigrateToSavedPokemon(t *testing.T) {
+	v2 := []byte(`{
+		"version": 2,
+		"created_at": "2026-01-02T03:04:05Z",
+		"updated_at": "2026-02-03T04:05:06Z",
+		"app_version": "v1.2.0",
+		"location": "eterna-forest-area",
+		"wild": {"name": "zubat", "level": 3},
+		"pokedex": {
+			"pikachu": {
+				"name": "pikachu",
+				"image_hash": "abc123",
+				"height": 4,
+				"weight": 60,
+				"base_experience": 112,
+				"abilities": [{"ability": {"name": "static"}, "slot": 1}],
+				"moves": [{"move": {"name": "thunder-shock"}}],
+				"species": {"name": "pikachu", "url": "https://pokeapi.co/api/v2/pokemon-species/25/"},
+				"sprites": {"other": {"official-artwork": {"front_shiny": "https://example.com/25-shiny.png"}}},
+				"stats": [
+					{"base_stat": 35, "effort": 0, "stat": {"name": "hp"}},
+					{"base_stat": 55, "effort": 0, "stat": {"name": "attack"}}
+				],
+				"types": [{"slot": 1, "type": {"name": "electric"}}]
+			}
+		}
+	}`)
+
+	data, changed, err := migrate(v2)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if !changed {
+		t.Error("migrate() reported no change for a version 2 save")
+	}
+	if bytes.Contains(data, []byte("thunder-shock")) {
+		t.Errorf("fields the app doesn't use were kept: %s", data)
+	}
+
+	save := saveFile{}
+	if err := json.Unmarshal(data, &save); err != nil {
+		t.Fatal(err)
+	}
+	if save.Version != 3 || save.AppVersion != "v1.2.0" || save.Location != "eterna-forest-area" ||
+		save.Wild == nil || save.Wild.Name != "zubat" || save.CreatedAt.Year() != 2026 {
+		t.Errorf("envelope was not kept: %+v", save)
+	}
+
+	want := savedPokemon{
+		Name:           "pikachu",
+		Species:        "pikachu",
+		Height:         4,
+		Weight:         60,
+		BaseExperience: 112,
+		Stats:          []savedStat{{"hp", 35}, {"attack", 55}},
+		Types:          []string{"electric"},
+		ImageHash:      "abc123",
+		ShinyImageURL:  "https://example.com/25-shiny.png",
+	}
+	if got := save.Pokedex["pikachu"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("pikachu =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSavedPokemonRoundTrip(t *testing.T) {
+	saved := savedPokemon{
+		Name:           "bulbasaur",
+		Species:        "bulbasaur",
+		Height:         7,
+		Weight:         69,
+		BaseExperience: 64,
+		Stats:          []savedStat{{"hp", 45}, {"attack", 49}, {"defense", 49}},
+		Types:          []string{"grass", "poison"},
+		ImageHash:      "def456",
+	}
+
+	if got := newSavedPokemon(saved.pokemon()); !reflect.DeepEqual(got, saved) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, saved)
+	}
+}
+
+func TestMigrateCurrentVersion(t *testing.T) {
+	current := []byte(`{"version": 3, "pokedex": {"pikachu": {"name": "pikachu", "stats": [], "types": []}}}`)
+
+	data, changed, err := migrate(current)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if changed || !bytes.Equal(data, current) {
+		t.Errorf("migrate() changed a save in the current format: %s", data)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantCorrupt bool
+	}{
+		// an older binary must leave a newer save alone instead of replacing it by a backup
+		{"newer version", `{"version": 99, "pokedex": {}}`, false},
+		{"not JSON", `{broken`, true},
+		{"version is not a number", `{"version": "two"}`, true},
+		{"bare map of something else", `{"pikachu": 25}`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+			_, _, err := migrate([]byte(test.data))
+			if err == nil {
+				t.Fatal("migrate() succeeded, want an error")
+			}
+			if errors.Is(err, ErrCorrupt) != test.wantCorrupt {
+				t.Errorf("migrate() error = %v, corrupt %v, want %v", err, errors.Is(err, ErrCorrupt), test.wantCorrupt)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package pokesave
+
+import "github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+
+// savedPokemon is what the save keeps of a caught Pokémon: only the fields the app uses,
+// so that changes to the PokeAPI types don't change the save format
+type savedPokemon struct {
+	Name           string      `json:"name"`
+	Species        string      `json:"species,omitempty"`
+	Height         int         `json:"height"`
+	Weight         int         `json:"weight"`
+	BaseExperience int         `json:"base_experience"`
+	Stats          []savedStat `json:"stats"`
+	Types          []string    `json:"types"`
+	ImageHash      string      `json:"image_hash,omitempty"`
+	// ShinyImageURL lets inspect --shiny download the artwork of a Pokémon that isn't cached
+	ShinyImageURL string `json:"shiny_image_url,omitempty"`
+}
+
+type savedStat struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// newSavedPokemon keeps what the save needs of pokemon. The image is left out,
+// the caller stores it as an asset and sets ImageHash
+func newSavedPokemon(pokemon pokeapi.Pokemon) savedPokemon {
+	saved := savedPokemon{
+		Name:           pokemon.Name,
+		Species:        pokemon.Species.Name,
+		Height:         pokemon.Height,
+		Weight:         pokemon.Weight,
+		BaseExperience: pokemon.BaseExperience,
+		Stats:          []savedStat{},
+		Types:          []string{},
+		ImageHash:      pokemon.ImageHash,
+		ShinyImageURL:  pokemon.Sprites.Other.OfficialArtwork.FrontShiny,
+	}
+	for _, value := range pokemon.Stats {
+		saved.Stats = append(saved.Stats, savedStat{Name: value.Stat.Name, Value: value.BaseStat})
+	}
+	for _, value := range pokemon.Types {
+		saved.Types = append(saved.Types, value.Type.Name)
+	}
+	return saved
+}
+
+// pokemon turns the saved Pokémon back into the PokeAPI type the app works with
+func (s savedPokemon) pokemon() pokeapi.Pokemon {
+	pokemon := pokeapi.Pokemon{
+		Name:           s.Name,
+		Height:         s.Height,
+		Weight:         s.Weight,
+		BaseExperience: s.BaseExperience,
+		ImageHash:      s.ImageHash,
+	}
+	pokemon.Species.Name = s.Species
+	pokemon.Sprites.Other.OfficialArtwork.FrontShiny = s.ShinyImageURL
+
+	for _, value := range s.Stats {
+		stat := pokeapi.PokemonStat{BaseStat: value.Value}
+		stat.Stat.Name = value.Name
+		pokemon.Stats = append(pokemon.Stats, stat)
+	}
+	for i, name := range s.Types {
+		pokemonType := pokeapi.PokemonType{Slot: i + 1}
+		pokemonType.Type.Name = name
+		pokemon.Types = append(pokemon.Types, pokemonType)
+	}
+	return pokemon
+}
@@ -0,0 +1,175 @@
+package pokesave
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokepaths"
+)
+
+const (
+	DefaultProfile = "default"
+
+	profilesDir       = "profiles"
+	activeProfileFile = "active-profile"
+	historyFileName   = "history"
+)
+
+var (
+	ErrProfileExists   = errors.New("profile already exists")
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrInvalidProfile  = errors.New("profile names may only contain letters, digits, '-' and '_'")
+)
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("%s: %w", name, ErrInvalidProfile)
+	}
+	return nil
+}
+
+// ListProfiles returns the names of all profiles that have a directory
+func ListProfiles() ([]string, error) {
+	dir, err := profilesRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileNameRe.MatchString(entry.Name()) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	slices.Sort(profiles)
+	return profiles, nil
+}
+
+func ProfileExists(name string) (bool, error) {
+	dir, err := profileDir(name)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+func CreateProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s: %w", name, ErrProfileExists)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteProfile removes the profile with all its saves and backups
+func DeleteProfile(name string) error {
+	exists, err := ProfileExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", name, ErrProfileNotFound)
+	}
+
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// ActiveProfile returns the profile used last time, or DefaultProfile
+func ActiveProfile() string {
+	dir, err := pokepaths.ConfigDir()
+	if err != nil {
+		return DefaultProfile
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, activeProfileFile))
+	if err != nil {
+		return DefaultProfile
+	}
+
+	name := strings.TrimSpace(string(data))
+	if ValidateProfileName(name) != nil {
+		return DefaultProfile
+	}
+	return name
+}
+
+// SetActiveProfile remembers the profile to use on the next start
+func SetActiveProfile(name string) error {
+	dir, err := pokepaths.ConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, activeProfileFile), []byte(name+"\n"))
+}
+
+// HistoryFilePath is where the commands entered by a profile are remembered
+func HistoryFilePath(name string) (string, error) {
+	dir, err := profileDir(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFileName), nil
+}
+
+func profilesRoot() (string, error) {
+	dir, err := pokepaths.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("can't locate data directory: %w", err)
+	}
+	return filepath.Join(dir, profilesDir), nil
+}
+
+func profileDir(name string) (string, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+
+	root, err := profilesRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, name), nil
+}
@@ -1,41 +1,221 @@
 package pokesave
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
 
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
 )
 
-const savePath = "./saves/"
+const (
+	saveFileName = "pokedex.json"
+
+	// CurrentVersion is the save format version written by SaveProgress
+	CurrentVersion = 3
+)
+
+// ErrCorrupt means the save can't be parsed. Only such saves are replaced by a backup
+var ErrCorrupt = errors.New("save is corrupt")
+
+// saveFile is the envelope around the saved Pokedex. Location is the area the trainer
+// travelled to last and Wild the Pokémon met there, saves from before travelling have neither
+type saveFile struct {
+	Version    int                     `json:"version"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	AppVersion string                  `json:"app_version"`
+	Location   string                  `json:"location,omitempty"`
+	Wild       *pokeapi.WildPokemon    `json:"wild,omitempty"`
+	Pokedex    map[string]savedPokemon `json:"pokedex"`
+}
 
 func SaveProgress(cfg *pokeapi.Config) error {
-	if err := os.MkdirAll(savePath, 0755); err != nil {
+	path, err := SaveFilePath(cfg)
+	if err != nil {
+		return fmt.Errorf("save progress error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("save progress error: %w", err)
 	}
 
-	data, err := json.MarshalIndent(cfg.PokemonCaught, "", " ")
+	// images go to the asset directory, the save only references them by hash
+	pokedex := make(map[string]savedPokemon, len(cfg.PokemonCaught))
+	for name, pokemon := range cfg.PokemonCaught {
+		if len(pokemon.Image) > 0 {
+			hash, err := storeAsset(pokemon.Image)
+			if err != nil {
+				return fmt.Errorf("save progress error: %w", err)
+			}
+			pokemon.ImageHash = hash
+		}
+		pokedex[name] = newSavedPokemon(pokemon)
+	}
+
+	now := time.Now().UTC()
+	if cfg.SaveCreatedAt.IsZero() {
+		cfg.SaveCreatedAt = now
+	}
+
+	data, err := json.MarshalIndent(saveFile{
+		Version:    CurrentVersion,
+		CreatedAt:  cfg.SaveCreatedAt,
+		UpdatedAt:  now,
+		AppVersion: appVersion(),
+		Location:   cfg.Location,
+		Wild:       cfg.Wild,
+		Pokedex:    pokedex,
+	}, "", " ")
 	if err != nil {
 		return fmt.Errorf("save progress error: %w", err)
 	}
 
-	if err = os.WriteFile("./saves/pokedex.json", data, 0644); err != nil {
+	if err = writeFileWithBackups(path, data); err != nil {
 		return fmt.Errorf("save progress error: %w", err)
 	}
 	return nil
 }
 
-func LoadProgress(cfg *pokeapi.Config) error {
-	pokedexData, err := os.ReadFile("./saves/pokedex.json")
+// Recovery describes a save that couldn't be loaded and the backup that was loaded instead
+type Recovery struct {
+	Reason     error
+	BackupPath string
+	UpdatedAt  time.Time
+	Pokemon    int
+}
+
+// LoadProgress loads the save into cfg. If the save is missing or corrupt, the newest valid backup
+// is loaded instead and described by the returned Recovery. The broken save is kept
+// as pokedex.json.corrupt so that the next save doesn't rotate it into the backups.
+// Other errors, e.g. a save written by a newer version, leave the save untouched
+func LoadProgress(cfg *pokeapi.Config) (*Recovery, error) {
+	path, err := SaveFilePath(cfg)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+		return nil, fmt.Errorf("load progress error: %w", err)
+	}
+
+	save, err := loadSaveFile(path)
+	if err == nil {
+		applySave(cfg, save)
+		return nil, nil
+	}
+
+	_, statErr := os.Stat(path)
+	missing := os.IsNotExist(statErr)
+	if !missing && !errors.Is(err, ErrCorrupt) {
+		return nil, fmt.Errorf("load progress error: %w", err)
+	}
+
+	for i := 1; i <= maxBackups; i++ {
+		backup, backupErr := loadSaveFile(backupPath(path, i))
+		if backupErr != nil {
+			continue
+		}
+
+		if !missing {
+			if err := os.Rename(path, path+".corrupt"); err != nil {
+				return nil, fmt.Errorf("load progress error: can't move broken save aside: %w", err)
+			}
 		}
+		applySave(cfg, backup)
+		return &Recovery{
+			Reason:     err,
+			BackupPath: backupPath(path, i),
+			UpdatedAt:  backup.UpdatedAt,
+			Pokemon:    len(backup.Pokedex),
+		}, nil
 	}
 
-	if err := json.Unmarshal(pokedexData, &cfg.PokemonCaught); err != nil {
-		return fmt.Errorf("load progress error: %w", err)
+	if missing {
+		return nil, nil
 	}
-	return nil
+	return nil, fmt.Errorf("load progress error: %w", err)
+}
+
+// SaveFilePath returns where the save of the profile in cfg lives
+func SaveFilePath(cfg *pokeapi.Config) (string, error) {
+	profile := cfg.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	dir, err := profileDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, saveFileName), nil
+}
+
+// loadSaveFile reads and parses a save, migrating it in place if it's in an older format
+func loadSaveFile(path string) (saveFile, error) {
+	save := saveFile{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return save, err
+	}
+
+	data, migrated, err := migrate(data)
+	if err != nil {
+		return save, err
+	}
+	if err := json.Unmarshal(data, &save); err != nil {
+		return save, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	if migrated {
+		if err := backupAndReplace(path, data); err != nil {
+			return save, err
+		}
+	}
+	return save, nil
+}
+
+func applySave(cfg *pokeapi.Config, save saveFile) {
+	for name, saved := range save.Pokedex {
+		pokemon := saved.pokemon()
+		if pokemon.ImageHash != "" {
+			// a missing image only means inspect can't draw the Pokémon
+			if image, err := loadAsset(pokemon.ImageHash); err == nil {
+				pokemon.Image = image
+			}
+		}
+		cfg.PokemonCaught[name] = pokemon
+	}
+	cfg.SaveCreatedAt = save.CreatedAt
+	cfg.Location = save.Location
+	cfg.Wild = save.Wild
+}
+
+// backupAndReplace keeps a copy of the save as it was before migration next to it
+func backupAndReplace(path string, migrated []byte) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	version, err := saveVersion(original)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(fmt.Sprintf("%s.v%d.bak", path, version), original); err != nil {
+		return fmt.Errorf("can't back up save before migration: %w", err)
+	}
+
+	indented := bytes.Buffer{}
+	if err := json.Indent(&indented, migrated, "", " "); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, indented.Bytes())
+}
+
+func appVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
 }
@@ -1,22 +1,33 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokecache"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeline"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokepaths"
 	"github.com/englandrecoil/go-pokedex-cli/internal/pokesave"
 	"github.com/fatih/color"
 )
 
 var cliName string = "pokedex "
 var errUndefinedCommand error = errors.New("command not found")
-var interval int = 1
+var defaultCacheTTL = time.Hour
+
+const (
+	httpCacheDir  = "http"
+	legacySaveDir = "./saves/"
+)
 
 func printPrompt() {
 	fmt.Print(cliName, "> ")
@@ -36,23 +47,89 @@ func printWelcomeMessage() {
 	fmt.Println()
 }
 
-func defineCommand(input string, cfg *pokeapi.Config) error {
-	cleanedInput := strings.Fields(strings.ToLower(input))
-	if len(cleanedInput) == 0 {
+func defineCommand(ctx context.Context, input string, cfg *pokeapi.Config) error {
+	words, err := splitWords(input)
+	if err != nil {
+		return err
+	}
+	return executeCommand(ctx, cfg, words)
+}
+
+// executeCommand runs a command given as separate words, the first one is the command name
+func executeCommand(ctx context.Context, cfg *pokeapi.Config, words []string) error {
+	if len(words) == 0 {
 		return nil
 	}
 
-	if command, exists := commands[cleanedInput[0]]; exists {
-		if err := command.callback(cfg, cleanedInput...); err != nil {
-			return err
+	command, exists := lookupCommand(strings.ToLower(words[0]))
+	if !exists {
+		return fmt.Errorf("%s: %w", words[0], errUndefinedCommand)
+	}
+
+	words = words[1:]
+	if !command.keepCase {
+		words = slices.Clone(words)
+		for i := range words {
+			words[i] = strings.ToLower(words[i])
 		}
-		return nil
+	}
+	args, err := command.parseArgs(words)
+	if err != nil {
+		return err
 	}
 
-	err := fmt.Errorf("%s: %w", input, errUndefinedCommand)
+	// a command may describe what it managed to do before failing
+	res, err := command.callback(ctx, cfg, args)
+	if res != nil {
+		if renderErr := render(ctx, res); renderErr != nil && err == nil {
+			return renderErr
+		}
+	}
 	return err
 }
 
+func printRecovery(recovery *pokesave.Recovery) {
+	if recovery == nil {
+		return
+	}
+	notice(color.FgYellow, "Your save couldn't be read (%s).", recovery.Reason)
+	notice(color.FgYellow, "Recovered %d Pokémon from the backup %s saved at %s.",
+		recovery.Pokemon, recovery.BackupPath, recovery.UpdatedAt.Local().Format(time.DateTime))
+}
+
+// newCache keeps responses on disk under the user cache directory so they survive restarts.
+// If the directory is unavailable the cache silently falls back to memory
+func newCache(memoryOnly bool) *pokecache.Cache {
+	ttl := defaultCacheTTL
+	if memoryOnly {
+		return pokecache.NewCache(ttl)
+	}
+
+	cacheDir, err := pokepaths.CacheDir()
+	if err != nil {
+		return pokecache.NewCache(ttl)
+	}
+	cache, err := pokecache.NewDiskCache(ttl, filepath.Join(cacheDir, httpCacheDir))
+	if err != nil {
+		return pokecache.NewCache(ttl)
+	}
+	return cache
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return value
+	}
+	return fallback
+}
+
 func init() {
 	if noColorVariable := os.Getenv("NO_COLOR"); noColorVariable == "" {
 		color.NoColor = false
@@ -62,33 +139,134 @@ func init() {
 }
 
 func main() {
+	apiURL := flag.String("api-url", os.Getenv("POKEAPI_BASE_URL"), "PokeAPI base URL (env POKEAPI_BASE_URL)")
+	spriteHost := flag.String("sprite-host", os.Getenv("POKEAPI_SPRITE_HOST"), "host that serves Pokémon sprites (env POKEAPI_SPRITE_HOST)")
+	userAgent := flag.String("user-agent", os.Getenv("POKEAPI_USER_AGENT"), "User-Agent sent to PokeAPI (env POKEAPI_USER_AGENT)")
+	timeout := flag.Duration("timeout", envDuration("POKEAPI_TIMEOUT", pokeapi.DefaultTimeout), "timeout of a single PokeAPI request (env POKEAPI_TIMEOUT)")
+	retries := flag.Int("retries", envInt("POKEAPI_RETRIES", pokeapi.DefaultMaxRetries), "how many times a failed PokeAPI request is retried (env POKEAPI_RETRIES)")
+	cacheSize := flag.String("cache-size", "64mb", "memory budget of the cache, 0 means no limit")
+	cacheEntries := flag.Int("cache-entries", 0, "maximum number of cached entries in memory, 0 means no limit")
+	profile := flag.String("profile", "", "trainer profile to play as (default: the last one switched to)")
+	noDiskCache := flag.Bool("no-disk-cache", false, "keep cached PokeAPI responses in memory only")
+	output := flag.String("output", outputText, "output format of commands: text, json or yaml")
+	script := flag.String("script", "", "run the commands of a script file and exit, - reads them from stdin")
+	failFast := flag.Bool("fail-fast", false, "stop a script at the first failed command")
+	echo := flag.Bool("echo", false, "print each command of a script before running it")
+	sandbox := flag.Bool("sandbox", false, "catch any Pokémon from anywhere, without travelling to where it lives")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command [arguments...]]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Without a command or a script the interactive Pokedex is started. Flags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if outputFormat = strings.ToLower(*output); !validOutputFormat(outputFormat) {
+		fmt.Fprintf(os.Stderr, "invalid -output: %s (expected text, json or yaml)\n", *output)
+		os.Exit(2)
+	}
+	if outputFormat != outputText {
+		// structured output must not contain escape sequences
+		color.NoColor = true
+	}
+
+	maxCacheBytes, err := parseSize(strings.ToLower(*cacheSize))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -cache-size: %s\n", err)
+		os.Exit(2)
+	}
+
+	client := pokeapi.NewClient(*apiURL, *spriteHost, *userAgent)
+	client.Timeout, client.MaxRetries = *timeout, max(*retries, 0)
+
 	cfg := &pokeapi.Config{
-		NextURL:       nil,
-		PreviousURL:   nil,
-		Cache:         pokecache.NewCache(time.Duration(interval) * time.Hour),
+		Client:        client,
+		Cache:         newCache(*noDiskCache),
 		PokemonCaught: make(map[string]pokeapi.Pokemon),
+		Profile:       pokesave.ActiveProfile(),
+		Sandbox:       *sandbox,
+	}
+	if *profile != "" {
+		if err := pokesave.ValidateProfileName(*profile); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -profile: %s\n", err)
+			os.Exit(2)
+		}
+		cfg.Profile = *profile
+	}
+
+	cfg.Cache.SetLimits(maxCacheBytes, max(*cacheEntries, 0))
+
+	if migratedTo, err := pokesave.MigrateLegacySaves(legacySaveDir); err != nil {
+		notice(color.FgRed, "can't import saves from %s: %s", legacySaveDir, err)
+	} else if migratedTo != "" {
+		notice(color.FgYellow, "Your Pokedex was imported from %s to %s. The old directory is no longer used.", legacySaveDir, migratedTo)
+	}
+
+	// piped input is a script too, so it gets no banner and no prompts
+	if flag.NArg() == 0 && *script == "" && !stdinIsTerminal() {
+		*script = "-"
 	}
 
-	if err := pokesave.LoadProgress(cfg); err != nil {
-		fmt.Println("No saves found")
+	recovery, err := pokesave.LoadProgress(cfg)
+	if err != nil {
+		// a script must not silently continue with an empty Pokedex
+		if flag.NArg() > 0 || *script != "" {
+			fmt.Fprintln(os.Stderr, color.RedString("%s", err))
+			os.Exit(exitFailure)
+		}
+		notice(color.FgRed, "%s", err)
+		notice(color.Reset, "Starting with an empty Pokedex")
+	}
+	printRecovery(recovery)
+
+	if flag.NArg() > 0 {
+		os.Exit(runOnce(cfg, flag.Args()))
 	}
 
-	reader := bufio.NewScanner(os.Stdin)
+	if *script != "" {
+		os.Exit(runScriptFile(cfg, *script, scriptOptions{failFast: *failFast, echo: *echo, errOut: os.Stderr}))
+	}
+
+	runner := &commandRunner{}
+	editor := pokeline.NewEditor(os.Stdin, os.Stdout)
+	editor.Complete = newCompleter(cfg)
+	runner.restoreTerminal = editor.Restore
+	runner.handleSignals(cfg)
+
 	red := color.New(color.FgRed).PrintlnFunc()
+	historyProfile := ""
 
 	printWelcomeMessage()
-	printPrompt()
 
-	for reader.Scan() {
-		if err := defineCommand(reader.Text(), cfg); err != nil {
-			if errors.Is(err, errUndefinedCommand) {
+	for {
+		// every profile has its own history
+		if cfg.Profile != historyProfile {
+			editor.History, historyProfile = loadHistory(cfg.Profile), cfg.Profile
+		}
+
+		input, err := editor.ReadLine(cliName + "> ")
+		if errors.Is(err, pokeline.ErrInterrupted) {
+			runner.interrupt(cfg)
+			continue
+		}
+		if err != nil {
+			break
+		}
+		if err := editor.History.Add(input); err != nil {
+			color.Red("can't save command history: %s", err)
+		}
+
+		if err := runner.run(cfg, input); err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				color.Yellow("Command cancelled")
+			case errors.Is(err, errNotCaught) && outputFormat == outputText:
+				// the result has already told how the Pokémon got away
+			case errors.Is(err, errUndefinedCommand) && outputFormat == outputText:
 				red(err)
 				fmt.Println("Use 'help' to view the available commands")
-			} else {
-				red(err)
+			default:
+				printError(os.Stdout, err)
 			}
 		}
-
-		printPrompt()
 	}
 }
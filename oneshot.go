@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// Exit codes of one-shot mode, so that scripts can tell failures apart
+const (
+	exitOK            = 0
+	exitFailure       = 1
+	exitUsage         = 2
+	exitNotFound      = 3
+	exitNetwork       = 4
+	exitNotCaught     = 5
+	exitAlreadyCaught = 6
+	exitCancelled     = 130
+)
+
+var errUsage = errors.New("usage error")
+
+type usageErr struct {
+	message string
+}
+
+func (e *usageErr) Error() string {
+	return e.message
+}
+
+func (e *usageErr) Is(target error) bool {
+	return target == errUsage
+}
+
+// usageError reports wrong or missing command arguments
+func usageError(format string, args ...any) error {
+	return &usageErr{message: fmt.Sprintf(format, args...)}
+}
+
+// runOnce executes a single command given on the command line and returns the exit code
+func runOnce(cfg *pokeapi.Config, args []string) int {
+	runner := &commandRunner{}
+	runner.handleSignals(cfg)
+
+	// the shell has already split and unquoted the arguments
+	err := runner.do(func(ctx context.Context) error {
+		return executeCommand(ctx, cfg, args)
+	})
+	if err == nil {
+		return exitOK
+	}
+
+	printError(os.Stderr, err)
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitCancelled
+	case errors.Is(err, errUsage), errors.Is(err, errUndefinedCommand):
+		return exitUsage
+	case errors.Is(err, pokeapi.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, pokeapi.ErrNetwork), errors.Is(err, pokeapi.ErrUpstream),
+		errors.Is(err, pokeapi.ErrRateLimited), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	case errors.Is(err, errNotCaught):
+		return exitNotCaught
+	case errors.Is(err, errAlreadyCaught):
+		return exitAlreadyCaught
+	default:
+		return exitFailure
+	}
+}
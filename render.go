@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFormat is set once from the --output flag
+var outputFormat = outputText
+
+func validOutputFormat(format string) bool {
+	return format == outputText || format == outputJSON || format == outputYAML
+}
+
+func render(ctx context.Context, res result) error {
+	if res == nil {
+		return nil
+	}
+
+	switch outputFormat {
+	case outputJSON:
+		return writeJSON(os.Stdout, res)
+	case outputYAML:
+		return writeYAML(os.Stdout, res)
+	default:
+		return res.printText(ctx)
+	}
+}
+
+// notice prints a message that isn't the result of a command, like a recovered save.
+// Structured modes send it to stderr so that stdout stays parseable
+func notice(attribute color.Attribute, format string, args ...any) {
+	w := io.Writer(os.Stdout)
+	if outputFormat != outputText {
+		w = os.Stderr
+	}
+	color.New(attribute).Fprintf(w, format+"\n", args...)
+}
+
+// printError reports a failed command. Structured modes emit an object with the message
+// and the exit code a one-shot run would end with
+func printError(w io.Writer, err error) {
+	if outputFormat == outputText {
+		fmt.Fprintln(w, color.RedString("%s", err))
+		return
+	}
+
+	failure := struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}{err.Error(), exitCode(err)}
+
+	if outputFormat == outputYAML {
+		writeYAML(w, failure)
+		return
+	}
+	writeJSON(w, failure)
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeYAML goes through JSON so the same struct tags apply. Decoding the JSON token by token
+// keeps the field order of the structs
+func writeYAML(w io.Writer, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := decodeOrdered(decoder)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	encodeYAML(&builder, node, 0)
+	_, err = io.WriteString(w, "---\n"+builder.String())
+	return err
+}
+
+type yamlField struct {
+	key   string
+	value any
+}
+
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		fields := []yamlField{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yamlField{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return fields, err
+	case json.Delim('['):
+		items := []any{}
+		for decoder.More() {
+			item, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = decoder.Token()
+		return items, err
+	default:
+		return token, nil
+	}
+}
+
+func encodeYAML(builder *strings.Builder, node any, indent int) {
+	padding := strings.Repeat("  ", indent)
+
+	switch value := node.(type) {
+	case []yamlField:
+		for _, field := range value {
+			builder.WriteString(padding + yamlScalar(field.key) + ":")
+			writeYAMLChild(builder, field.value, indent)
+		}
+	case []any:
+		for _, item := range value {
+			// a mapping inside a list starts on the line of its dash
+			if fields, ok := item.([]yamlField); ok && len(fields) > 0 {
+				var nested strings.Builder
+				encodeYAML(&nested, fields, indent+1)
+				builder.WriteString(padding + "- " + strings.TrimPrefix(nested.String(), padding+"  "))
+				continue
+			}
+			builder.WriteString(padding + "-")
+			writeYAMLChild(builder, item, indent)
+		}
+	default:
+		builder.WriteString(padding + yamlScalar(value) + "\n")
+	}
+}
+
+// writeYAMLChild writes the value of a mapping key or list item that was already written
+func writeYAMLChild(builder *strings.Builder, node any, indent int) {
+	switch value := node.(type) {
+	case []yamlField:
+		if len(value) == 0 {
+			builder.WriteString(" {}\n")
+			return
+		}
+	case []any:
+		if len(value) == 0 {
+			builder.WriteString(" []\n")
+			return
+		}
+	default:
+		builder.WriteString(" " + yamlScalar(value) + "\n")
+		return
+	}
+
+	builder.WriteString("\n")
+	encodeYAML(builder, node, indent+1)
+}
+
+var plainYAMLString = regexp.MustCompile(`^[\p{L}_/][\p{L}\p{N}_ ./-]*$`)
+
+var reservedYAMLWords = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "null": true, "y": true, "n": true, "~": true,
+}
+
+func yamlScalar(value any) string {
+	switch scalar := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if plainYAMLString.MatchString(scalar) && !strings.HasSuffix(scalar, " ") &&
+			!reservedYAMLWords[strings.ToLower(scalar)] {
+			return scalar
+		}
+		// a JSON string is a valid double-quoted YAML scalar
+		quoted, _ := json.Marshal(scalar)
+		return string(quoted)
+	default:
+		return fmt.Sprint(scalar)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokedraw"
+	"github.com/fatih/color"
+)
+
+// result is what a command produces. In text mode printText is called,
+// in json and yaml modes the value itself is encoded, so only exported fields end up there
+type result interface {
+	printText(ctx context.Context) error
+}
+
+type messageResult struct {
+	Message string `json:"message"`
+	color   color.Attribute
+}
+
+func message(format string, args ...any) messageResult {
+	return messageResult{Message: fmt.Sprintf(format, args...)}
+}
+
+func coloredMessage(attribute color.Attribute, format string, args ...any) messageResult {
+	return messageResult{Message: fmt.Sprintf(format, args...), color: attribute}
+}
+
+func (r messageResult) printText(ctx context.Context) error {
+	if r.color == 0 {
+		fmt.Println(r.Message)
+		return nil
+	}
+	color.New(r.color).Println(r.Message)
+	return nil
+}
+
+type locationsResult struct {
+	Region    string   `json:"region,omitempty"`
+	Page      int      `json:"page"`
+	Pages     int      `json:"pages"`
+	Count     int      `json:"count"`
+	Locations []string `json:"locations"`
+}
+
+func (r locationsResult) printText(ctx context.Context) error {
+	for _, name := range r.Locations {
+		fmt.Println(" - " + name)
+	}
+
+	position := fmt.Sprintf("page %d/%d", r.Page, r.Pages)
+	if r.Region != "" {
+		position += ", region " + r.Region
+	}
+	fmt.Println(color.BlueString(position))
+	return nil
+}
+
+type exploreResult struct {
+	Location    string         `json:"location"`
+	Version     string         `json:"version,omitempty"`
+	MethodRates []methodRate   `json:"method_rates"`
+	Encounters  []encounterRow `json:"encounters"`
+	Pokemon     []string       `json:"pokemon"`
+}
+
+func (r exploreResult) printText(ctx context.Context) error {
+	color.Set(color.FgBlue)
+	fmt.Printf("Exploring %s...\n", r.Location)
+	if len(r.Encounters) == 0 {
+		if r.Version != "" {
+			fmt.Printf("No Pokémon can be found here in %s\n", r.Version)
+		} else {
+			fmt.Println("No Pokémon can be found here")
+		}
+		color.Unset()
+		return nil
+	}
+	fmt.Println("Found Pokemon:")
+	color.Unset()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	header := "POKEMON\tCHANCE\tLEVELS\tMETHOD"
+	if r.Version == "" {
+		header += "\tVERSIONS"
+	}
+	fmt.Fprintln(writer, " "+header)
+
+	for _, encounter := range r.Encounters {
+		row := fmt.Sprintf(" %s\t%d%%\t%s\t%s", encounter.Pokemon, encounter.Chance,
+			formatLevels(encounter.MinLevel, encounter.MaxLevel), encounter.Method)
+		if r.Version == "" {
+			row += "\t" + strings.Join(encounter.Versions, ", ")
+		}
+		fmt.Fprintln(writer, row)
+	}
+	writer.Flush()
+
+	if len(r.MethodRates) > 0 {
+		var rates []string
+		for _, rate := range r.MethodRates {
+			rates = append(rates, fmt.Sprintf("%s %d%%", rate.Method, rate.Rate))
+		}
+		fmt.Println(color.BlueString("Encounter rates: ") + strings.Join(rates, ", "))
+	}
+	return nil
+}
+
+type whereResult struct {
+	Pokemon  string              `json:"pokemon"`
+	Version  string              `json:"version,omitempty"`
+	Versions []versionEncounters `json:"versions"`
+}
+
+func (r whereResult) printText(ctx context.Context) error {
+	if len(r.Versions) == 0 {
+		color.Set(color.FgBlue)
+		defer color.Unset()
+		if r.Version != "" {
+			fmt.Printf("%s can't be found in the wild in %s\n", r.Pokemon, r.Version)
+		} else {
+			fmt.Printf("%s can't be found in the wild\n", r.Pokemon)
+		}
+		return nil
+	}
+
+	for i, group := range r.Versions {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(color.BlueString("%s:", group.Version))
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(writer, " AREA\tCHANCE\tLEVELS\tMETHOD")
+		for _, area := range group.Areas {
+			fmt.Fprintf(writer, " %s\t%d%%\t%s\t%s\n", area.Area, area.Chance,
+				formatLevels(area.MinLevel, area.MaxLevel), area.Method)
+		}
+		writer.Flush()
+	}
+	return nil
+}
+
+type catchResult struct {
+	Pokemon string `json:"pokemon"`
+	// Location and Chance are empty in the sandbox, where every Pokémon can be found
+	Location string `json:"location,omitempty"`
+	Chance   int    `json:"chance,omitempty"`
+	// Level is set for a wild Pokémon met with encounter
+	Level       int    `json:"level,omitempty"`
+	Found       bool   `json:"found"`
+	Ball        string `json:"ball"`
+	CaptureRate int    `json:"capture_rate"`
+	Shakes      int    `json:"shakes"`
+	Caught      bool   `json:"caught"`
+}
+
+// shakeMessages are what the games say when a Pokémon breaks free after that many shakes
+var shakeMessages = []string{
+	"Oh no! %s broke free!",
+	"Aww! %s appeared to be caught!",
+	"Aargh! Almost had %s!",
+	"Shoot! %s was so close, too!",
+}
+
+func (r catchResult) printText(ctx context.Context) error {
+	color.Set(color.FgBlue)
+	defer color.Unset()
+
+	if r.Location != "" {
+		fmt.Printf("Looking for %s in %s (%d%% chance)...\n", r.Pokemon, r.Location, r.Chance)
+	}
+	if !r.Found {
+		color.Set(color.FgRed)
+		fmt.Printf("No %s showed up, try again\n", r.Pokemon)
+		return nil
+	}
+
+	if r.Level > 0 {
+		fmt.Printf("Throwing a %s at the wild %s (Lv. %d)...\n", r.Ball, r.Pokemon, r.Level)
+	} else {
+		fmt.Printf("Throwing a %s at %s...\n", r.Ball, r.Pokemon)
+	}
+	for range r.Shakes {
+		if err := waitTurn(ctx); err != nil {
+			return err
+		}
+		fmt.Println("...shake")
+	}
+
+	if !r.Caught {
+		color.Set(color.FgRed)
+		fmt.Printf(shakeMessages[r.Shakes]+"\n", r.Pokemon)
+		return nil
+	}
+
+	color.Set(color.FgGreen)
+	fmt.Printf("%s was caught!\n", r.Pokemon)
+	color.Set(color.FgBlue)
+	fmt.Println("You may now inspect it with the 'inspect' command.")
+	return nil
+}
+
+type encounterResult struct {
+	Location string `json:"location"`
+	Method   string `json:"method"`
+	Pokemon  string `json:"pokemon"`
+	Level    int    `json:"level"`
+	Chance   int    `json:"chance"`
+}
+
+func (r encounterResult) printText(ctx context.Context) error {
+	color.Set(color.FgBlue)
+	defer color.Unset()
+
+	fmt.Printf("Looking for Pokémon in %s (%s)...\n", r.Location, r.Method)
+	color.Set(color.FgGreen)
+	fmt.Printf("A wild %s (Lv. %d) appeared!\n", r.Pokemon, r.Level)
+	color.Set(color.FgBlue)
+	fmt.Println("Catch it with 'catch', fight it with 'battle {pokemon_name}' or run with 'flee'")
+	return nil
+}
+
+type travelResult struct {
+	Location string   `json:"location"`
+	Pokemon  []string `json:"pokemon"`
+	// Moved is false when the trainer only asked where they are
+	Moved bool `json:"moved"`
+}
+
+func (r travelResult) printText(ctx context.Context) error {
+	color.Set(color.FgBlue)
+	defer color.Unset()
+
+	switch {
+	case r.Location == "":
+		fmt.Println("You haven't travelled anywhere yet, pick an area with 'map' and go there with 'travel'")
+		return nil
+	case r.Moved:
+		fmt.Printf("You travelled to %s\n", r.Location)
+	default:
+		fmt.Printf("You are in %s\n", r.Location)
+	}
+
+	if len(r.Pokemon) == 0 {
+		fmt.Println("No Pokémon live here")
+	} else {
+		fmt.Printf("Pokémon living here: %s\n", strings.Join(r.Pokemon, ", "))
+	}
+	return nil
+}
+
+type statValue struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+type inspectResult struct {
+	Name      string      `json:"name"`
+	Height    int         `json:"height"`
+	Weight    int         `json:"weight"`
+	Stats     []statValue `json:"stats"`
+	Types     []string    `json:"types"`
+	Shiny     bool        `json:"shiny,omitempty"`
+	ImageHash string      `json:"image_hash,omitempty"`
+	image     []byte
+}
+
+func (r inspectResult) printText(ctx context.Context) error {
+	fmt.Println(color.BlueString("Name: ") + r.Name)
+	fmt.Println(color.BlueString("Height: ") + strconv.Itoa(r.Height))
+	fmt.Println(color.BlueString("Weight: ") + strconv.Itoa(r.Weight))
+	fmt.Println(color.BlueString("Stats: "))
+
+	for _, stat := range r.Stats {
+		fmt.Printf(" - "+color.BlueString("%s: ")+"%d\n", stat.Name, stat.Value)
+	}
+
+	fmt.Println(color.BlueString("Types: "))
+	for _, name := range r.Types {
+		fmt.Printf(" - "+"%s\n", name)
+	}
+
+	fmt.Println(color.BlueString("Image: "))
+	if err := pokedraw.DisplayImage(r.image); err != nil {
+		return fmt.Errorf("display image error: %w", err)
+	}
+	fmt.Println()
+	return nil
+}
+
+type pokedexResult struct {
+	Pokemon []string `json:"pokemon"`
+}
+
+func (r pokedexResult) printText(ctx context.Context) error {
+	if len(r.Pokemon) == 0 {
+		fmt.Println(color.BlueString("Your pokedex is empty! Try to catch Pokemon with 'catch' command"))
+		return nil
+	}
+
+	fmt.Println(color.BlueString("Your pokedex:"))
+	for _, name := range r.Pokemon {
+		fmt.Println(" - " + name)
+	}
+	return nil
+}
+
+type cacheStatsResult struct {
+	Entries      int    `json:"entries"`
+	MaxEntries   int    `json:"max_entries"`
+	Bytes        int64  `json:"bytes"`
+	MaxBytes     int64  `json:"max_bytes"`
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Expirations  uint64 `json:"expirations"`
+	TTL          string `json:"ttl"`
+	ReapInterval string `json:"reap_interval"`
+	Persistent   bool   `json:"persistent"`
+}
+
+func (r cacheStatsResult) printText(ctx context.Context) error {
+	entriesLimit, bytesLimit := "none", "none"
+	if r.MaxEntries > 0 {
+		entriesLimit = strconv.Itoa(r.MaxEntries)
+	}
+	if r.MaxBytes > 0 {
+		bytesLimit = formatSize(r.MaxBytes)
+	}
+
+	fmt.Printf("Entries in memory: %d (limit: %s)\n", r.Entries, entriesLimit)
+	fmt.Printf("Memory used: %s (limit: %s)\n", formatSize(r.Bytes), bytesLimit)
+	fmt.Printf("Hits: %d, misses: %d\n", r.Hits, r.Misses)
+	fmt.Printf("Evicted: %d, expired: %d\n", r.Evictions, r.Expirations)
+	fmt.Printf("Entry lifetime (TTL): %s\n", r.TTL)
+	fmt.Printf("Cleaning interval: %s\n", r.ReapInterval)
+	fmt.Printf("Persisted on disk: %t\n", r.Persistent)
+	return nil
+}
+
+type profilesResult struct {
+	Active   string   `json:"active"`
+	Profiles []string `json:"profiles"`
+}
+
+func (r profilesResult) printText(ctx context.Context) error {
+	for _, profile := range r.Profiles {
+		if profile == r.Active {
+			fmt.Println(color.GreenString(" * " + profile))
+			continue
+		}
+		fmt.Println("   " + profile)
+	}
+	return nil
+}
+
+type pathsResult struct {
+	Data   string `json:"data"`
+	Save   string `json:"save"`
+	Images string `json:"images"`
+	Cache  string `json:"cache"`
+	Config string `json:"config"`
+}
+
+func (r pathsResult) printText(ctx context.Context) error {
+	for _, path := range []struct{ label, value string }{
+		{"Data", r.Data},
+		{"Save", r.Save},
+		{"Images", r.Images},
+		{"Cache", r.Cache},
+		{"Config", r.Config},
+	} {
+		fmt.Printf("%s %s\n", color.BlueString("%-14s", path.label+":"), path.value)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+	"github.com/fatih/color"
+)
+
+// scripts may run other scripts, the limit stops a script that runs itself
+const maxScriptDepth = 8
+
+type scriptDepthKey struct{}
+
+type scriptOptions struct {
+	failFast bool
+	echo     bool
+	// errors of single commands are reported here as they happen
+	errOut io.Writer
+}
+
+type scriptFailure struct {
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Code    int    `json:"code"`
+}
+
+type scriptResult struct {
+	Script   string          `json:"script"`
+	Commands int             `json:"commands"`
+	Failed   int             `json:"failed"`
+	Stopped  bool            `json:"stopped"`
+	Failures []scriptFailure `json:"failures"`
+}
+
+func (r scriptResult) printText(ctx context.Context) error {
+	if len(r.Failures) == 0 {
+		color.Green("%s: %d commands ran without errors", r.Script, r.Commands)
+		return nil
+	}
+
+	// the number of failures is reported by the error the run ends with
+	fmt.Println("Failed commands:")
+	for _, failure := range r.Failures {
+		fmt.Printf(" - line %d: %s\n", failure.Line, failure.Error)
+	}
+	if r.Stopped {
+		color.Yellow("Stopped at the first failure, the rest of the script was skipped")
+	}
+	return nil
+}
+
+// scriptError is returned when some commands of a script failed. It unwraps to the first
+// failure, so the exit code reflects what went wrong first
+type scriptError struct {
+	res   scriptResult
+	first error
+}
+
+func (e *scriptError) Error() string {
+	return fmt.Sprintf("%s: %d of %d commands failed", e.res.Script, e.res.Failed, e.res.Commands)
+}
+
+func (e *scriptError) Unwrap() error {
+	return e.first
+}
+
+// runScript executes the commands of a script one per line. Blank lines and lines
+// starting with '#' are skipped
+func runScript(ctx context.Context, cfg *pokeapi.Config, name string, r io.Reader, opts scriptOptions) (scriptResult, error) {
+	depth, _ := ctx.Value(scriptDepthKey{}).(int)
+	if depth >= maxScriptDepth {
+		return scriptResult{}, fmt.Errorf("%s: scripts are nested more than %d levels deep", name, maxScriptDepth)
+	}
+	ctx = context.WithValue(ctx, scriptDepthKey{}, depth+1)
+
+	res := scriptResult{Script: name, Failures: []scriptFailure{}}
+	var first error
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		if opts.echo {
+			echoCommand(line)
+		}
+		res.Commands++
+
+		err := defineCommand(ctx, line, cfg)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, context.Canceled) {
+			return res, err
+		}
+
+		printError(opts.errOut, fmt.Errorf("line %d: %w", lineNumber, err))
+		res.Failed++
+		res.Failures = append(res.Failures, scriptFailure{
+			Line:    lineNumber,
+			Command: line,
+			Error:   err.Error(),
+			Code:    exitCode(err),
+		})
+		if first == nil {
+			first = err
+		}
+		if opts.failFast {
+			res.Stopped = true
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return res, fmt.Errorf("can't read %s: %w", name, err)
+	}
+
+	if first != nil {
+		return res, &scriptError{res: res, first: first}
+	}
+	return res, nil
+}
+
+// echoCommand shows the command the way it would look in the REPL. Structured output
+// goes to stderr so stdout stays parseable
+func echoCommand(line string) {
+	if outputFormat == outputText {
+		fmt.Println(color.CyanString("%s> %s", cliName, line))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s> %s\n", cliName, line)
+}
+
+func commandRun(ctx context.Context, cfg *pokeapi.Config, args commandArgs) (result, error) {
+	opts := scriptOptions{failFast: args.has("fail-fast"), echo: args.has("echo"), errOut: os.Stdout}
+	path := args.arg(0)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("run command error: %w", err)
+	}
+	defer file.Close()
+
+	res, err := runScript(ctx, cfg, path, file, opts)
+	if err != nil {
+		var failed *scriptError
+		if errors.As(err, &failed) {
+			return res, err
+		}
+		return nil, fmt.Errorf("run command error: %w", err)
+	}
+	return res, nil
+}
+
+// runScriptFile runs a script given with --script and returns the exit code.
+// The path "-" reads the script from stdin
+func runScriptFile(cfg *pokeapi.Config, path string, opts scriptOptions) int {
+	runner := &commandRunner{}
+	runner.handleSignals(cfg)
+
+	var input io.Reader = os.Stdin
+	name := "stdin"
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			printError(os.Stderr, err)
+			return exitFailure
+		}
+		defer file.Close()
+		input, name = file, path
+	}
+
+	var res scriptResult
+	err := runner.do(func(ctx context.Context) error {
+		var err error
+		res, err = runScript(ctx, cfg, name, input, opts)
+		return err
+	})
+
+	var failed *scriptError
+	if err != nil && !errors.As(err, &failed) {
+		printError(os.Stderr, err)
+		return exitCode(err)
+	}
+	// the summary is only interesting when something failed
+	if failed != nil {
+		if outputFormat == outputText {
+			res.printText(context.Background())
+		}
+		printError(os.Stderr, err)
+	}
+	return exitCode(err)
+}
+
+// stdinIsTerminal tells an interactive session apart from piped input
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokesave"
+	"github.com/fatih/color"
+)
+
+// commandRunner runs REPL commands with a context that Ctrl+C cancels.
+// Ctrl+C with nothing to cancel arms the runner, and the next one saves progress and exits
+type commandRunner struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	armed  bool
+	// busy is held while a command runs, so that exiting never saves a config a command is changing
+	busy sync.Mutex
+	// restoreTerminal leaves the raw mode of the line editor before exiting
+	restoreTerminal func()
+}
+
+func (r *commandRunner) run(cfg *pokeapi.Config, input string) error {
+	return r.do(func(ctx context.Context) error {
+		return defineCommand(ctx, input, cfg)
+	})
+}
+
+// do calls fn with a context that is cancelled by the next Ctrl+C
+func (r *commandRunner) do(fn func(ctx context.Context) error) error {
+	r.busy.Lock()
+	defer r.busy.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r.mu.Lock()
+	r.cancel, r.armed = cancel, false
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.cancel, r.armed = nil, false
+		r.mu.Unlock()
+	}()
+
+	return fn(ctx)
+}
+
+func (r *commandRunner) handleSignals(cfg *pokeapi.Config) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGTERM {
+				r.exit(cfg)
+			}
+			if r.interrupt(cfg) {
+				printPrompt()
+			}
+		}
+	}()
+}
+
+// interrupt handles Ctrl+C, either as a signal or read by the line editor. It reports
+// whether nothing was running, so that the caller shows the prompt again
+func (r *commandRunner) interrupt(cfg *pokeapi.Config) bool {
+	r.mu.Lock()
+	if r.armed {
+		r.mu.Unlock()
+		fmt.Println()
+		r.exit(cfg)
+	}
+	defer r.mu.Unlock()
+	r.armed = true
+
+	if r.cancel != nil {
+		r.cancel()
+		color.Yellow("\nCancelling... press Ctrl+C again to save and exit")
+		return false
+	}
+	color.Yellow("\nPress Ctrl+C again to save and exit")
+	return true
+}
+
+// exitTimeout is how long exit waits for a cancelled command before saving anyway
+const exitTimeout = 3 * time.Second
+
+// exit cancels the running command and waits for it to stop before saving
+func (r *commandRunner) exit(cfg *pokeapi.Config) {
+	r.mu.Lock()
+	running := r.cancel != nil
+	if running {
+		r.cancel()
+	}
+	r.mu.Unlock()
+
+	if running {
+		color.Yellow("Waiting for the command to stop...")
+	}
+	stopped := make(chan struct{})
+	go func() {
+		r.busy.Lock()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(exitTimeout):
+		color.Yellow("The command didn't stop in time, saving anyway")
+	}
+
+	if r.restoreTerminal != nil {
+		r.restoreTerminal()
+	}
+	saveAndExit(cfg)
+}
+
+func saveAndExit(cfg *pokeapi.Config) {
+	if err := pokesave.SaveProgress(cfg); err != nil {
+		color.Red("can't save progress before exiting: %s", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/englandrecoil/go-pokedex-cli/internal/pokeapi"
+)
+
+// notFoundError replaces the raw HTTP status in the message with something readable
+// while keeping the original error reachable through errors.Is/errors.As
+type notFoundError struct {
+	message string
+	err     error
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// notFound reports something missing that isn't a PokeAPI resource, like a Pokémon
+// that isn't in the Pokedex. It exits with the same code as a PokeAPI not found
+func notFound(format string, args ...any) error {
+	return &notFoundError{message: fmt.Sprintf(format, args...), err: pokeapi.ErrNotFound}
+}
+
+// wrapAPIError turns pokeapi.ErrNotFound into "no <kind> named <name>" with a suggestion
+// of the closest known name. Other errors are wrapped as is
+func wrapAPIError(ctx context.Context, cfg *pokeapi.Config, commandName, kind, resource, name string, err error) error {
+	if !errors.Is(err, pokeapi.ErrNotFound) {
+		return fmt.Errorf("%s command error: %w", commandName, err)
+	}
+
+	message := fmt.Sprintf("%s command error: no %s named %s", commandName, kind, name)
+	if suggestion := suggestName(ctx, cfg, resource, name); suggestion != "" {
+		message += fmt.Sprintf(". Did you mean %s?", suggestion)
+	}
+	return &notFoundError{message: message, err: err}
+}
+
+// suggestName returns the closest name of the resource within a small edit distance.
+// Failure to fetch the list of names just means there is no suggestion
+func suggestName(ctx context.Context, cfg *pokeapi.Config, resource, name string) string {
+	names, err := pokeapi.GetResourceNames(ctx, cfg, resource)
+	if err != nil {
+		return ""
+	}
+	return closestName(name, names)
+}
+
+// closestName returns the candidate with the smallest edit distance to name, if it is small enough
+func closestName(name string, candidates []string) string {
+	maxDistance := len(name)/3 + 1
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if distance := levenshtein(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}